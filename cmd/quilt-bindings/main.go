@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"github.com/wolferton/quilt/config"
 	"github.com/wolferton/quilt/facility/jsonmerger"
+	"github.com/wolferton/quilt/ioc"
 	"github.com/wolferton/quilt/logging"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -22,12 +24,24 @@ const (
 	ofLocationDefault  string = "bindings/bindings.go"
 	ofLocationHelp     string = "Path of the Go source file that will be generated"
 
+	gfLocationFlagName string = "g"
+	gfLocationDefault  string = ""
+	gfLocationHelp     string = "Path of a file the component dependency graph will be written to (no graph is written if omitted)"
+
+	gfFormatFlagName string = "gf"
+	gfFormatDefault  string = "dot"
+	gfFormatHelp     string = "Format of the dependency graph file (dot or json)"
+
 	llFlagName string = "l"
 	llDefault  string = "ERROR"
 	llHelp     string = "Minimum importance of logging to be displayed (TRACE, DEBUG, INFO, WARN, ERROR, FATAL)"
 
-	nameField = "name"
-	typeField = "type"
+	nameField  = "name"
+	typeField  = "type"
+	scopeField = "scope"
+	lazyField  = "lazy"
+
+	prototypeScope = "prototype"
 
 	deferSeparator = ":"
 	refPrefix      = "ref"
@@ -41,6 +55,8 @@ func main() {
 	var cdf = flag.String(cdfLocationFlagName, cdfLocationDefault, cdfLocationHelp)
 	var of = flag.String(ofLocationFlagName, ofLocationDefault, ofLocationHelp)
 	var ll = flag.String(llFlagName, llDefault, llHelp)
+	var gf = flag.String(gfLocationFlagName, gfLocationDefault, gfLocationHelp)
+	var gff = flag.String(gfFormatFlagName, gfFormatDefault, gfFormatHelp)
 
 	flag.Parse()
 
@@ -56,6 +72,8 @@ func main() {
 	command.ComponentDefinitions = expandedFileList
 	command.OutputFile = *of
 	command.LogLevel = *ll
+	command.GraphFile = *gf
+	command.GraphFormat = *gff
 
 	command.Execute()
 
@@ -70,6 +88,8 @@ type CreateBindingsCommand struct {
 	OutputFile           string
 	ComponentDefinitions []string
 	LogLevel             string
+	GraphFile            string
+	GraphFormat          string
 }
 
 func (cbc *CreateBindingsCommand) Execute() int {
@@ -79,15 +99,109 @@ func (cbc *CreateBindingsCommand) Execute() int {
 	jsonMerger := new(jsonmerger.JsonMerger)
 	jsonMerger.Logger = cbc.logger
 
-	mergedConfig := jsonMerger.LoadAndMergeConfig(cbc.ComponentDefinitions)
+	mergedConfig, err := jsonMerger.LoadAndMergeConfig(cbc.ComponentDefinitions)
 
-	configAccessor := config.ConfigAccessor{mergedConfig, nil}
+	if err != nil {
+		cbc.logger.LogFatalf("%s", err)
+		os.Exit(-1)
+	}
+
+	configAccessor := config.ConfigAccessor{JsonData: mergedConfig}
 
 	cbc.writeBindingsSource(cbc.OutputFile, &configAccessor)
 
+	if cbc.GraphFile != "" {
+		cbc.writeGraph(cbc.GraphFile, cbc.GraphFormat, &configAccessor)
+	}
+
 	return 0
 }
 
+func (cbc *CreateBindingsCommand) writeGraph(outPath string, format string, configAccessor *config.ConfigAccessor) {
+
+	if format != "dot" && format != "json" {
+		cbc.logger.LogFatalf("Unsupported graph format %s (expected dot or json)", format)
+		os.Exit(-1)
+	}
+
+	cbc.logger.LogInfof("Writing dependency graph %s", outPath)
+
+	graph := cbc.buildGraph(configAccessor)
+
+	os.MkdirAll(path.Dir(outPath), 0777)
+	file, err := os.Create(outPath)
+
+	if err != nil {
+		cbc.logger.LogFatalf("%s", err)
+		os.Exit(-1)
+	}
+
+	defer file.Close()
+
+	if format == "dot" {
+		err = graph.WriteDot(file)
+	} else {
+		err = graph.WriteJson(file)
+	}
+
+	if err != nil {
+		cbc.logger.LogFatalf("%s", err)
+		os.Exit(-1)
+	}
+}
+
+func (cbc *CreateBindingsCommand) buildGraph(configAccessor *config.ConfigAccessor) *ioc.DependencyGraph {
+
+	graph := ioc.NewDependencyGraph()
+
+	components := configAccessor.ObjectVal("components")
+
+	for name, componentJson := range components {
+		component := componentJson.(map[string]interface{})
+
+		graph.AddComponent(name, configAccessor.StringFieldVal(typeField, component))
+
+		for fieldName, fieldContents := range component {
+
+			value, isString := fieldContents.(string)
+
+			if !isString || cbc.reservedFieldName(fieldName) {
+				continue
+			}
+
+			switch kind, instruction := deferredValue(value); kind {
+			case refPrefix:
+				graph.AddDependency(name, fieldName, instruction)
+			case confPrefix:
+				graph.AddConfigPromise(name, fieldName, instruction)
+			}
+		}
+	}
+
+	return graph
+}
+
+func deferredValue(value string) (string, string) {
+
+	valueElements := strings.SplitN(value, deferSeparator, 2)
+
+	if len(valueElements) != 2 {
+		return "", ""
+	}
+
+	prefix := valueElements[0]
+	instruction := valueElements[1]
+
+	switch prefix {
+	case refPrefix, refAlias:
+		return refPrefix, instruction
+	case confPrefix, confAlias:
+		return confPrefix, instruction
+	default:
+		return "", ""
+	}
+}
+
 func (cbc *CreateBindingsCommand) configureLogging() {
 	logLevel := logging.LogLevelFromLabel(cbc.LogLevel)
 
@@ -142,13 +256,13 @@ func (cbc *CreateBindingsCommand) writeBindingsSource(outPath string, configAcce
 
 				switch config.JsonType(fieldContents) {
 				case config.JsonMap:
-					cbc.writeMapValue(writer, instanceVariableName, fieldName, fieldContents.(map[string]interface{}))
+					cbc.writeMapValue(writer, fieldName, fieldContents.(map[string]interface{}), componentProtoName)
 				case config.JsonString:
-					cbc.writeStringValue(writer, instanceVariableName, fieldName, fieldContents.(string), componentProtoName)
+					cbc.writeStringValue(writer, fieldName, fieldContents.(string), componentProtoName)
 				case config.JsonBool:
-					cbc.writeBoolValue(writer, instanceVariableName, fieldName, fieldContents.(bool), componentProtoName)
+					cbc.writeBoolValue(writer, fieldName, fieldContents.(bool), componentProtoName)
 				case config.JsonArray:
-					cbc.writeArray(writer, instanceVariableName, fieldName, fieldContents.([]interface{}), componentProtoName)
+					cbc.writeArray(writer, fieldName, fieldContents.([]interface{}), componentProtoName)
 				case config.JsonUnknown:
 
 					switch t := fieldContents.(type) {
@@ -171,12 +285,10 @@ func (cbc *CreateBindingsCommand) writeBindingsSource(outPath string, configAcce
 	writer.Flush()
 }
 
-func (cbc *CreateBindingsCommand) writeArray(writer *bufio.Writer, instanceName string, fieldName string, fieldContents []interface{}, componentProtoName string) {
-
-	cbc.writeAssignmentPrefix(writer, instanceName, fieldName)
+func (cbc *CreateBindingsCommand) writeArray(writer *bufio.Writer, fieldName string, fieldContents []interface{}, componentProtoName string) {
 
 	if cbc.allStrings(fieldContents) {
-		cbc.writeStringArrayContents(writer, fieldContents)
+		cbc.writeProperty(writer, componentProtoName, fieldName, cbc.stringArrayContents(fieldContents))
 	} else {
 		cbc.logger.LogErrorf("Unsupported data types in array %#v", fieldContents)
 		os.Exit(-1)
@@ -184,24 +296,15 @@ func (cbc *CreateBindingsCommand) writeArray(writer *bufio.Writer, instanceName
 
 }
 
-func (cbc *CreateBindingsCommand) writeStringArrayContents(w *bufio.Writer, a []interface{}) {
-
-	w.WriteString(" []string{")
+func (cbc *CreateBindingsCommand) stringArrayContents(a []interface{}) string {
 
-	last := len(a) - 1
+	quoted := make([]string, len(a))
 
 	for i, s := range a {
-
-		w.WriteString(fmt.Sprintf("%q", s.(string)))
-
-		if i != last {
-			w.WriteString(", ")
-		}
-
+		quoted[i] = fmt.Sprintf("%q", s.(string))
 	}
 
-	w.WriteString("}\n")
-
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
 }
 
 func (cbc *CreateBindingsCommand) allStrings(a []interface{}) bool {
@@ -220,85 +323,75 @@ func (cbc *CreateBindingsCommand) allStrings(a []interface{}) bool {
 	return true
 }
 
-func (cbc *CreateBindingsCommand) writeBoolValue(writer *bufio.Writer, instanceName string, fieldName string, fieldContents bool, componentProtoName string) {
-
-	cbc.writeAssignmentPrefix(writer, instanceName, fieldName)
-	writer.WriteString(strconv.FormatBool(fieldContents))
-	writer.WriteString("\n")
-
+func (cbc *CreateBindingsCommand) writeBoolValue(writer *bufio.Writer, fieldName string, fieldContents bool, componentProtoName string) {
+	cbc.writeProperty(writer, componentProtoName, fieldName, strconv.FormatBool(fieldContents))
 }
 
-func (cbc *CreateBindingsCommand) writeStringValue(writer *bufio.Writer, instanceName string, fieldName string, fieldContents string, componentProtoName string) {
-
-	valueElements := strings.SplitN(fieldContents, deferSeparator, 2)
+func (cbc *CreateBindingsCommand) writeStringValue(writer *bufio.Writer, fieldName string, fieldContents string, componentProtoName string) {
 
-	if len(valueElements) == 2 {
+	kind, instruction := deferredValue(fieldContents)
 
-		prefix := valueElements[0]
-		instruction := valueElements[1]
+	if kind == refPrefix {
 
-		if prefix == refPrefix || prefix == refAlias {
-
-			writer.WriteString("\t")
-			writer.WriteString(componentProtoName)
-			writer.WriteString(".AddDependency(\"")
-			writer.WriteString(fieldName)
-			writer.WriteString("\", \"")
-			writer.WriteString(instruction)
-			writer.WriteString("\")\n")
-
-			return
+		writer.WriteString("\t")
+		writer.WriteString(componentProtoName)
+		writer.WriteString(".AddDependency(\"")
+		writer.WriteString(fieldName)
+		writer.WriteString("\", \"")
+		writer.WriteString(instruction)
+		writer.WriteString("\")\n")
 
-		} else if prefix == confPrefix || prefix == confAlias {
+		return
 
-			writer.WriteString("\t")
-			writer.WriteString(componentProtoName)
-			writer.WriteString(".AddConfigPromise(\"")
-			writer.WriteString(fieldName)
-			writer.WriteString("\", \"")
-			writer.WriteString(instruction)
-			writer.WriteString("\")\n")
+	} else if kind == confPrefix {
 
-			return
-		}
+		writer.WriteString("\t")
+		writer.WriteString(componentProtoName)
+		writer.WriteString(".AddConfigPromise(\"")
+		writer.WriteString(fieldName)
+		writer.WriteString("\", \"")
+		writer.WriteString(instruction)
+		writer.WriteString("\")\n")
+
+		return
 	}
 
-	cbc.writeAssignmentPrefix(writer, instanceName, fieldName)
-	writer.WriteString(fmt.Sprintf("%q", fieldContents))
-	writer.WriteString("\n")
+	cbc.writeProperty(writer, componentProtoName, fieldName, fmt.Sprintf("%q", fieldContents))
 }
 
-func (cbc *CreateBindingsCommand) writeMapValue(writer *bufio.Writer, instanceName string, fieldName string, fieldContents map[string]interface{}) {
+func (cbc *CreateBindingsCommand) writeMapValue(writer *bufio.Writer, fieldName string, fieldContents map[string]interface{}, componentProtoName string) {
 
-	directField := instanceName + "." + fieldName
+	keys := make([]string, 0, len(fieldContents))
 
-	writer.WriteString(directField)
-	writer.WriteString(" = make(map[string]string)\n")
+	for key := range fieldContents {
+		keys = append(keys, key)
+	}
 
-	for key, value := range fieldContents {
-		writer.WriteString("\t")
-		writer.WriteString(directField)
-		writer.WriteString("[\"")
-		writer.WriteString(key)
-		writer.WriteString("\"] = \"")
-		writer.WriteString(value.(string))
-		writer.WriteString("\"\n")
+	sort.Strings(keys)
+
+	entries := make([]string, len(keys))
+
+	for i, key := range keys {
+		entries[i] = fmt.Sprintf("%q: %q", key, fieldContents[key].(string))
 	}
 
+	cbc.writeProperty(writer, componentProtoName, fieldName, "map[string]string{"+strings.Join(entries, ", ")+"}")
 }
 
-func (cbc *CreateBindingsCommand) writeAssignmentPrefix(writer *bufio.Writer, instanceName string, fieldName string) {
+func (cbc *CreateBindingsCommand) writeProperty(writer *bufio.Writer, componentProtoName string, fieldName string, value string) {
 
 	writer.WriteString("\t")
-	writer.WriteString(instanceName)
-	writer.WriteString(".")
+	writer.WriteString(componentProtoName)
+	writer.WriteString(".AddProperty(\"")
 	writer.WriteString(fieldName)
-	writer.WriteString(" = ")
+	writer.WriteString("\", ")
+	writer.WriteString(value)
+	writer.WriteString(")\n")
 
 }
 
 func (cbc *CreateBindingsCommand) reservedFieldName(field string) bool {
-	return field == nameField || field == typeField
+	return field == nameField || field == typeField || field == scopeField || field == lazyField
 }
 
 func (cbc *CreateBindingsCommand) writeComponentWrapper(writer *bufio.Writer, configAccessor *config.ConfigAccessor, component map[string]interface{}, name string, index int, instanceName string) string {
@@ -321,6 +414,18 @@ func (cbc *CreateBindingsCommand) writeComponentWrapper(writer *bufio.Writer, co
 	writer.WriteString(name)
 	writer.WriteString("\"\n")
 
+	if component[scopeField] == prototypeScope {
+		writer.WriteString("\t")
+		writer.WriteString(componentProtoName)
+		writer.WriteString(".Scope = ioc.PrototypeScope\n")
+	}
+
+	if component[lazyField] == true {
+		writer.WriteString("\t")
+		writer.WriteString(componentProtoName)
+		writer.WriteString(".Lazy = true\n")
+	}
+
 	return componentProtoName
 }
 
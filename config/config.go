@@ -1,14 +1,17 @@
 package config
 
 import (
+	"fmt"
 	"github.com/wolferton/quilt/logging"
-	"os"
 	"reflect"
 	"strings"
+	"time"
 )
 
 const JsonPathSeparator string = "."
 
+var durationType = reflect.TypeOf(time.Duration(0))
+
 const (
 	JsonUnknown     = -1
 	JsonInt         = 0
@@ -24,6 +27,7 @@ type ConfigValue interface{}
 type ConfigAccessor struct {
 	JsonData        map[string]interface{}
 	FrameworkLogger logging.Logger
+	overrides       map[string]string
 }
 
 func (c *ConfigAccessor) PathExists(path string) bool {
@@ -60,11 +64,18 @@ func (c *ConfigAccessor) StringFieldVal(field string, object map[string]interfac
 }
 
 func (c *ConfigAccessor) IntValue(path string) int {
-	return int(c.Value(path).(float64))
+	return int(c.Float64Value(path))
 }
 
 func (c *ConfigAccessor) Float64Value(path string) float64 {
-	return c.Value(path).(float64)
+
+	f, err := c.Float64(path)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return f
 }
 
 func (c *ConfigAccessor) Array(path string) []interface{} {
@@ -79,7 +90,14 @@ func (c *ConfigAccessor) Array(path string) []interface{} {
 }
 
 func (c *ConfigAccessor) BoolValue(path string) bool {
-	return c.Value(path).(bool)
+
+	b, err := c.Bool(path)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return b
 }
 
 func JsonType(value interface{}) int {
@@ -111,11 +129,17 @@ func (c *ConfigAccessor) configValue(path []string, jsonMap map[string]interface
 		return result
 	} else {
 		remainPath := path[1:len(path)]
-		return c.configValue(remainPath, result.(map[string]interface{}))
+		object, isObject := result.(map[string]interface{})
+
+		if !isObject {
+			return nil
+		}
+
+		return c.configValue(remainPath, object)
 	}
 }
 
-func (ca *ConfigAccessor) SetField(fieldName string, path string, target interface{}) {
+func (ca *ConfigAccessor) SetField(fieldName string, path string, target interface{}) error {
 
 	targetReflect := reflect.ValueOf(target).Elem()
 	targetField := targetReflect.FieldByName(fieldName)
@@ -124,48 +148,155 @@ func (ca *ConfigAccessor) SetField(fieldName string, path string, target interfa
 
 	switch k {
 	case reflect.String:
-		targetField.SetString(ca.StringVal(path))
+		s, err := ca.String(path)
+
+		if err != nil {
+			return err
+		}
+
+		targetField.SetString(s)
 	case reflect.Bool:
-		targetField.SetBool(ca.BoolValue(path))
+		b, err := ca.Bool(path)
+
+		if err != nil {
+			return err
+		}
+
+		targetField.SetBool(b)
 	case reflect.Int:
-		targetField.SetInt(int64(ca.IntValue(path)))
+		i, err := ca.Int(path)
+
+		if err != nil {
+			return err
+		}
+
+		targetField.SetInt(int64(i))
+	case reflect.Int64:
+		var i int64
+		var err error
+
+		if targetField.Type() == durationType {
+			var d time.Duration
+			d, err = ca.Duration(path)
+			i = int64(d)
+		} else {
+			i, err = ca.Int64(path)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		targetField.SetInt(i)
+	case reflect.Uint:
+		u, err := ca.Uint(path)
+
+		if err != nil {
+			return err
+		}
+
+		targetField.SetUint(uint64(u))
+	case reflect.Float64:
+		f, err := ca.Float64(path)
+
+		if err != nil {
+			return err
+		}
+
+		targetField.SetFloat(f)
+	case reflect.Slice:
+		return ca.populateSliceField(fieldName, path, targetField)
 	case reflect.Map:
-		ca.populateMapField(targetField, ca.ObjectVal(path))
+		o, err := ca.Object(path)
+
+		if err != nil {
+			return err
+		}
+
+		return ca.populateMapField(path, targetField, o)
+
+	default:
+		reason := fmt.Sprintf("target field %s is not a suppported type (%s)", fieldName, k)
+		return &ConfigTypeError{path, reason}
+	}
+
+	return nil
+}
+
+func (ca *ConfigAccessor) populateSliceField(fieldName string, path string, targetField reflect.Value) error {
 
+	var v interface{}
+	var err error
+
+	switch targetField.Type().Elem().Kind() {
+	case reflect.String:
+		v, err = ca.StringArray(path)
+	case reflect.Int:
+		v, err = ca.IntArray(path)
+	case reflect.Float64:
+		v, err = ca.Float64Array(path)
+	case reflect.Bool:
+		v, err = ca.BoolArray(path)
 	default:
-		ca.FrameworkLogger.LogErrorf("Unable to use value at path %s as target field %s is not a suppported type (%s)", path, fieldName, k)
+		reason := fmt.Sprintf("target field %s is not a suppported type (%s)", fieldName, targetField.Type())
+		return &ConfigTypeError{path, reason}
+	}
+
+	if err != nil {
+		return err
 	}
 
+	targetField.Set(reflect.ValueOf(v).Convert(targetField.Type()))
+
+	return nil
 }
 
-func (ca *ConfigAccessor) populateMapField(targetField reflect.Value, contents map[string]interface{}) {
+func (ca *ConfigAccessor) populateMapField(path string, targetField reflect.Value, contents map[string]interface{}) error {
 	m := reflect.MakeMap(targetField.Type())
 	targetField.Set(m)
 
+	elemType := targetField.Type().Elem()
+
 	for k, v := range contents {
 
 		kVal := reflect.ValueOf(k)
 		vVal := reflect.ValueOf(v)
 
-		if vVal.Kind() == reflect.Slice {
-			vVal = ca.arrayValue(vVal)
+		if elemType == durationType {
+
+			d, err := toDuration(path+JsonPathSeparator+k, v)
+
+			if err != nil {
+				return err
+			}
+
+			vVal = reflect.ValueOf(d)
+
+		} else if vVal.Kind() == reflect.Slice {
+
+			var err error
+			vVal, err = ca.arrayValue(path+JsonPathSeparator+k, vVal)
+
+			if err != nil {
+				return err
+			}
 		}
 
 		m.SetMapIndex(kVal, vVal)
 
 	}
 
+	return nil
 }
 
 //TODO support arrays other than string arrays
-func (ca *ConfigAccessor) arrayValue(a reflect.Value) reflect.Value {
+func (ca *ConfigAccessor) arrayValue(path string, a reflect.Value) (reflect.Value, error) {
 
 	v := a.Interface().([]interface{})
 	l := len(v)
 
 	if l == 0 {
-		ca.FrameworkLogger.LogFatalf("Cannot use an empty array as a value in a Map.")
-		os.Exit(-1)
+		return a, &ConfigTypeError{path, "cannot use an empty array as a value in a map"}
 	}
 
 	var s reflect.Value
@@ -174,8 +305,8 @@ func (ca *ConfigAccessor) arrayValue(a reflect.Value) reflect.Value {
 	case string:
 		s = reflect.MakeSlice(reflect.TypeOf([]string{}), 0, 0)
 	default:
-		ca.FrameworkLogger.LogFatalf("Cannot use an array of %T as a value in a Map.", t)
-		os.Exit(-1)
+		reason := fmt.Sprintf("cannot use an array of %T as a value in a map", t)
+		return a, &ConfigTypeError{path, reason}
 	}
 
 	for _, elem := range v {
@@ -184,10 +315,10 @@ func (ca *ConfigAccessor) arrayValue(a reflect.Value) reflect.Value {
 
 	}
 
-	return s
+	return s, nil
 }
 
-func (ca *ConfigAccessor) Populate(path string, target interface{}) {
+func (ca *ConfigAccessor) Populate(path string, target interface{}) error {
 	exists := ca.PathExists(path)
 
 	if exists {
@@ -202,7 +333,11 @@ func (ca *ConfigAccessor) Populate(path string, target interface{}) {
 			expectedConfigPath := path + JsonPathSeparator + fieldName
 
 			if ca.PathExists(expectedConfigPath) {
-				ca.SetField(fieldName, expectedConfigPath, target)
+				err := ca.SetField(fieldName, expectedConfigPath, target)
+
+				if err != nil {
+					return err
+				}
 			}
 
 		}
@@ -211,4 +346,5 @@ func (ca *ConfigAccessor) Populate(path string, target interface{}) {
 		ca.FrameworkLogger.LogErrorf("Trying to populate an object from a JSON object, but the base path %s does not exist", path)
 	}
 
+	return nil
 }
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type ConfigDecoder interface {
+	Decode(data []byte) (map[string]interface{}, error)
+}
+
+type DecodeError struct {
+	Line   int
+	Reason string
+}
+
+func (de *DecodeError) Error() string {
+	return fmt.Sprintf("line %d: %s", de.Line, de.Reason)
+}
+
+var decoderMutex sync.RWMutex
+
+var decoders = map[string]ConfigDecoder{
+	".json": new(JsonDecoder),
+	".yaml": new(YamlDecoder),
+	".yml":  new(YamlDecoder),
+	".toml": new(TomlDecoder),
+}
+
+func RegisterDecoder(extension string, decoder ConfigDecoder) {
+
+	decoderMutex.Lock()
+	defer decoderMutex.Unlock()
+
+	decoders[strings.ToLower(extension)] = decoder
+}
+
+func IsConfigFile(fileName string) bool {
+
+	decoderMutex.RLock()
+	defer decoderMutex.RUnlock()
+
+	return decoders[strings.ToLower(filepath.Ext(fileName))] != nil
+}
+
+func DecoderFor(fileName string) ConfigDecoder {
+
+	decoderMutex.RLock()
+	defer decoderMutex.RUnlock()
+
+	if decoder := decoders[strings.ToLower(filepath.Ext(fileName))]; decoder != nil {
+		return decoder
+	}
+
+	return decoders[".json"]
+}
+
+type JsonDecoder struct {
+}
+
+func (jd *JsonDecoder) Decode(data []byte) (map[string]interface{}, error) {
+
+	var decoded interface{}
+
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+
+	object, found := decoded.(map[string]interface{})
+
+	if !found {
+		return nil, fmt.Errorf("expected a JSON object at the top level, found %T", decoded)
+	}
+
+	return object, nil
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+const decoderJson = `{
+  "HttpServer": {"Port": 8080, "AccessLogging": false, "ContentType": "application/json"},
+  "QueryManager": {"NewLine": "\n", "StringWrapWith": "'", "Hosts": ["a", "b # not a comment"]},
+  "Databases": [{"Name": "orders", "Replicas": [1, 2]}, {"Name": "audit", "Options": {}}],
+  "Query": "SELECT *\nFROM orders\n"
+}`
+
+const decoderYaml = `# Server settings
+HttpServer:
+  Port: 8080            # default port
+  AccessLogging: false
+  ContentType: application/json
+QueryManager:
+  NewLine: "\n"
+  StringWrapWith: "'"
+  Hosts:
+  - a
+  - "b # not a comment"
+Databases:
+  - Name: orders
+    Replicas: [1, 2]
+  - Name: audit
+    Options: {}
+Query: |
+  SELECT *
+  FROM orders
+`
+
+const decoderToml = `# Server settings
+Query = """
+SELECT *
+FROM orders
+"""
+
+[HttpServer]
+Port = 8080 # default port
+AccessLogging = false
+ContentType = "application/json"
+
+[QueryManager]
+NewLine = "\n"
+StringWrapWith = "'"
+Hosts = [
+  "a",
+  "b # not a comment",
+]
+
+[[Databases]]
+Name = "orders"
+Replicas = [1, 2]
+
+[[Databases]]
+Name = 'audit'
+Options = {}
+`
+
+func TestDecodersProduceEquivalentConfig(t *testing.T) {
+
+	expected, err := DecoderFor("config.json").Decode([]byte(decoderJson))
+
+	if err != nil {
+		t.Fatalf("Unexpected error decoding JSON: %s", err)
+	}
+
+	for _, name := range []string{"config.yaml", "config.yml", "config.toml"} {
+
+		source := decoderYaml
+
+		if name == "config.toml" {
+			source = decoderToml
+		}
+
+		decoded, err := DecoderFor(name).Decode([]byte(source))
+
+		if err != nil {
+			t.Errorf("Unexpected error decoding %s: %s", name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(expected, decoded) {
+			t.Errorf("Expected %s to decode to %v, got %v", name, expected, decoded)
+		}
+	}
+
+	if _, err := DecoderFor("broken.yaml").Decode([]byte("A:\n  B: 1\n   C: 2\n")); err == nil {
+		t.Errorf("Expected inconsistent indentation to be reported")
+	} else if de, found := err.(*DecodeError); !found || de.Line != 3 {
+		t.Errorf("Expected the error to name line 3, got %v", err)
+	}
+
+	if _, err := DecoderFor("broken.toml").Decode([]byte("[A]\nB = 1\nB = 2\n")); err == nil {
+		t.Errorf("Expected a duplicate key to be reported")
+	}
+
+	if !IsConfigFile("settings.YML") || IsConfigFile("README.md") {
+		t.Errorf("Expected config files to be recognised by extension")
+	}
+
+}
+
+func TestYamlConstructs(t *testing.T) {
+
+	cases := []struct {
+		source   string
+		expected map[string]interface{}
+	}{
+		{"A: [1,\n  2]\n", map[string]interface{}{"A": []interface{}{float64(1), float64(2)}}},
+		{"A: {B: x, # comment\n  C: y}\nD: z\n", map[string]interface{}{"A": map[string]interface{}{"B": "x", "C": "y"}, "D": "z"}},
+		{"A: first\n  second\n\n  third\nB: x\n", map[string]interface{}{"A": "first second\nthird", "B": "x"}},
+		{"A:\n  first\n  second\n", map[string]interface{}{"A": "first second"}},
+		{"A: \"first\n  second\\\n  third\"\n", map[string]interface{}{"A": "first secondthird"}},
+		{"A: 'it''s\n\n  here'\n", map[string]interface{}{"A": "it's\nhere"}},
+		{"Key:\tvalue\n", map[string]interface{}{"Key": "value"}},
+		{"A: \"a\\/b \\x41\\u00e9 \\_\"\n", map[string]interface{}{"A": "a/b A\u00e9 \u00a0"}},
+		{"A: |+\n  x\n", map[string]interface{}{"A": "x\n"}},
+		{"A: |+\n  x\n\n", map[string]interface{}{"A": "x\n\n"}},
+		{"1.0: a\n0x10: b\n\"~\": c\n", map[string]interface{}{"1.0": "a", "0x10": "b", "~": "c"}},
+		{"A: {1.0: a}\n", map[string]interface{}{"A": map[string]interface{}{"1.0": "a"}}},
+	}
+
+	for _, c := range cases {
+
+		decoded, err := DecoderFor("config.yaml").Decode([]byte(c.source))
+
+		if err != nil {
+			t.Errorf("Unexpected error decoding %q: %s", c.source, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(c.expected, decoded) {
+			t.Errorf("Expected %q to decode to %q, got %q", c.source, c.expected, decoded)
+		}
+	}
+
+	for _, source := range []string{"~: a\n", "A: {null: a}\n", "A: \"\\q\"\n", "A: \"\\'\"\n", "A: [1,\n  2\n"} {
+
+		if _, err := DecoderFor("config.yaml").Decode([]byte(source)); err == nil {
+			t.Errorf("Expected an error decoding %q", source)
+		}
+	}
+
+}
+
+func TestTomlRedefinitionsRejected(t *testing.T) {
+
+	for _, source := range []string{
+		"[[a]]\nx = 1\n[a]\ny = 2\n",
+		"a = {x = 1}\n[a]\ny = 2\n",
+		"a = {x = 1}\n[a.b]\ny = 2\n",
+		"a = {x = 1}\na.y = 2\n",
+		"a = [1, 2]\n[[a]]\n",
+		"[a]\n[[a]]\n",
+	} {
+
+		if _, err := DecoderFor("config.toml").Decode([]byte(source)); err == nil {
+			t.Errorf("Expected an error decoding %q", source)
+		}
+	}
+
+	decoded, err := DecoderFor("config.toml").Decode([]byte("[[a]]\n[a.b]\nx = 1\n[[a]]\n[a.b]\nx = 2\n"))
+
+	if err != nil {
+		t.Fatalf("Unexpected error decoding sub-tables of an array of tables: %s", err)
+	}
+
+	if tables := decoded["a"].([]interface{}); len(tables) != 2 {
+		t.Errorf("Expected two tables, got %v", tables)
+	}
+
+}
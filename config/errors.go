@@ -0,0 +1,22 @@
+package config
+
+import (
+	"fmt"
+)
+
+type ConfigTypeError struct {
+	Path   string
+	Reason string
+}
+
+func (cte *ConfigTypeError) Error() string {
+	return fmt.Sprintf("Unable to use the configuration at path %s: %s", cte.Path, cte.Reason)
+}
+
+type MissingConfigError struct {
+	Path string
+}
+
+func (mce *MissingConfigError) Error() string {
+	return fmt.Sprintf("No configuration found at path %s", mce.Path)
+}
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
-	"strings"
 )
 
 func FindConfigFilesInDir(dirPath string) ([]string, error) {
@@ -27,7 +26,7 @@ func findConfigFilesInDir(dirPath string) ([]string, error) {
 
 		if info.Mode().IsDir() {
 
-		} else if strings.HasSuffix(fileName, ".json") {
+		} else if IsConfigFile(fileName) {
 
 			files = append(files, dirPath+"/"+fileName)
 		}
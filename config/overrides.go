@@ -0,0 +1,242 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/wolferton/quilt/logging"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Overrides are layered on top of the merged JSON configuration. From lowest to
+// highest precedence: JSON files, QUILT_ environment variables, -D properties.
+// Environment variables only replace paths already present in the JSON, -D
+// properties may also introduce new paths.
+//
+// In environment variable names each _ separates two path elements and __ stands
+// for a literal underscore, so QUILT_Db_Max__Idle overrides Db.Max_Idle.
+
+const EnvironmentOverridePrefix = "QUILT_"
+const environmentPathSeparator = "_"
+const environmentEscapedSeparator = "__"
+
+const (
+	EnvironmentSource = "environment"
+	PropertySource    = "property"
+)
+
+type ConfigOverride struct {
+	Path          string
+	Value         string
+	Source        string
+	createMissing bool
+}
+
+func EnvironmentOverrides(environ []string) []*ConfigOverride {
+
+	overrides := []*ConfigOverride{}
+
+	for _, entry := range environ {
+
+		if !strings.HasPrefix(entry, EnvironmentOverridePrefix) {
+			continue
+		}
+
+		kv := strings.SplitN(strings.TrimPrefix(entry, EnvironmentOverridePrefix), "=", 2)
+
+		if len(kv) != 2 || kv[0] == "" {
+			continue
+		}
+
+		path := environmentPath(kv[0])
+
+		overrides = append(overrides, &ConfigOverride{path, kv[1], EnvironmentSource, false})
+	}
+
+	sortOverrides(overrides)
+
+	return overrides
+}
+
+func environmentPath(name string) string {
+
+	parts := strings.Split(name, environmentEscapedSeparator)
+
+	for i, part := range parts {
+		parts[i] = strings.Replace(part, environmentPathSeparator, JsonPathSeparator, -1)
+	}
+
+	return strings.Join(parts, environmentPathSeparator)
+}
+
+func PropertyOverrides(properties map[string]string) []*ConfigOverride {
+
+	overrides := []*ConfigOverride{}
+
+	for path, value := range properties {
+		overrides = append(overrides, &ConfigOverride{path, value, PropertySource, true})
+	}
+
+	sortOverrides(overrides)
+
+	return overrides
+}
+
+func NewLayeredConfigAccessor(jsonData map[string]interface{}, logger logging.Logger, layers ...[]*ConfigOverride) (*ConfigAccessor, error) {
+
+	if jsonData == nil {
+		jsonData = make(map[string]interface{})
+	}
+
+	ca := &ConfigAccessor{JsonData: jsonData, FrameworkLogger: logger}
+
+	for _, layer := range layers {
+
+		if err := ca.ApplyOverrides(layer); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ca.ResolvePlaceholders(); err != nil {
+		return nil, err
+	}
+
+	return ca, nil
+}
+
+func (ca *ConfigAccessor) ApplyOverrides(overrides []*ConfigOverride) error {
+
+	for _, o := range overrides {
+
+		existing := ca.Value(o.Path)
+
+		if existing == nil && !o.createMissing {
+			continue
+		}
+
+		value, err := coerceOverride(o, existing)
+
+		if err != nil {
+			return err
+		}
+
+		if err := ca.setValue(o.Path, value); err != nil {
+			return err
+		}
+
+		if ca.overrides == nil {
+			ca.overrides = make(map[string]string)
+		}
+
+		ca.overrides[o.Path] = o.Source
+
+		if ca.FrameworkLogger != nil {
+			ca.FrameworkLogger.LogDebugf("%s overridden by %s", o.Path, o.Source)
+		}
+	}
+
+	return nil
+}
+
+func (ca *ConfigAccessor) OverrideSource(path string) string {
+	return ca.overrides[path]
+}
+
+func (ca *ConfigAccessor) setValue(path string, value interface{}) error {
+
+	splitPath := strings.Split(path, JsonPathSeparator)
+	current := ca.JsonData
+
+	for i, key := range splitPath[:len(splitPath)-1] {
+
+		next, found := current[key]
+
+		if !found || next == nil {
+			created := make(map[string]interface{})
+			current[key] = created
+			current = created
+			continue
+		}
+
+		nextMap, isMap := next.(map[string]interface{})
+
+		if !isMap {
+			parent := strings.Join(splitPath[:i+1], JsonPathSeparator)
+			return &ConfigTypeError{path, fmt.Sprintf("%s is not an object and cannot contain %s", parent, path)}
+		}
+
+		current = nextMap
+	}
+
+	current[splitPath[len(splitPath)-1]] = value
+
+	return nil
+}
+
+func coerceOverride(o *ConfigOverride, existing interface{}) (interface{}, error) {
+
+	switch existing.(type) {
+	case nil:
+		return inferOverride(o.Value), nil
+	case string:
+		return o.Value, nil
+	case bool:
+		b, err := strconv.ParseBool(o.Value)
+
+		if err != nil {
+			return nil, overrideTypeError(o, "a bool")
+		}
+
+		return b, nil
+	case float64:
+		f, err := strconv.ParseFloat(o.Value, 64)
+
+		if err != nil {
+			return nil, overrideTypeError(o, "a number")
+		}
+
+		return f, nil
+	case []interface{}:
+		var a []interface{}
+
+		if err := json.Unmarshal([]byte(o.Value), &a); err != nil {
+			return nil, overrideTypeError(o, "a JSON array")
+		}
+
+		return a, nil
+	case map[string]interface{}:
+		var m map[string]interface{}
+
+		if err := json.Unmarshal([]byte(o.Value), &m); err != nil {
+			return nil, overrideTypeError(o, "a JSON object")
+		}
+
+		return m, nil
+	default:
+		return o.Value, nil
+	}
+}
+
+func inferOverride(value string) interface{} {
+
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b
+	}
+
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+
+	return value
+}
+
+func overrideTypeError(o *ConfigOverride, expected string) error {
+	return &ConfigTypeError{o.Path, fmt.Sprintf("%s override %q cannot be converted to %s", o.Source, o.Value, expected)}
+}
+
+func sortOverrides(overrides []*ConfigOverride) {
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].Path < overrides[j].Path
+	})
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestOverridePrecedence(t *testing.T) {
+
+	jsonData := map[string]interface{}{
+		"HttpServer": map[string]interface{}{"Port": float64(8080), "AccessLogging": false, "ContentType": "application/json"},
+	}
+
+	environ := []string{"QUILT_HttpServer_Port=9000", "QUILT_HttpServer_AccessLogging=true", "QUILT_HOME=/opt/quilt", "PATH=/bin"}
+	properties := map[string]string{"HttpServer.Port": "9100", "Database.Dsn": "postgres://localhost"}
+
+	ca, err := NewLayeredConfigAccessor(jsonData, nil, EnvironmentOverrides(environ), PropertyOverrides(properties))
+
+	if err != nil {
+		t.Fatalf("Unexpected error applying overrides: %s", err)
+	}
+
+	if port := ca.IntValue("HttpServer.Port"); port != 9100 || ca.OverrideSource("HttpServer.Port") != PropertySource {
+		t.Errorf("Expected the property to take precedence over the environment, got %d", port)
+	}
+
+	if !ca.BoolValue("HttpServer.AccessLogging") {
+		t.Errorf("Expected the environment to override the JSON value")
+	}
+
+	if ca.StringVal("Database.Dsn") != "postgres://localhost" {
+		t.Errorf("Expected a property to be able to add a new path")
+	}
+
+	if ca.PathExists("HOME") {
+		t.Errorf("Expected environment variables not to add new paths")
+	}
+
+	_, err = NewLayeredConfigAccessor(jsonData, nil, PropertyOverrides(map[string]string{"HttpServer.Port": "high"}))
+
+	if _, found := err.(*ConfigTypeError); !found {
+		t.Errorf("Expected a ConfigTypeError for an unconvertible override, got %v", err)
+	}
+
+}
+
+func TestEnvironmentOverridesEscapeUnderscores(t *testing.T) {
+
+	overrides := EnvironmentOverrides([]string{"QUILT_Db_Max__Idle=5", "QUILT_Db_Pool__Size_Min=1"})
+
+	if len(overrides) != 2 || overrides[0].Path != "Db.Max_Idle" || overrides[1].Path != "Db.Pool_Size.Min" {
+		t.Errorf("Expected __ to be read as a literal underscore, got %s and %s", overrides[0].Path, overrides[1].Path)
+	}
+
+}
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Placeholders of the form ${type:name} or ${type:name:default} in string values
+// are replaced with an environment variable, a config value or a file's contents.
+// A placeholder that makes up a whole value keeps the referenced value's type; a
+// referenced object or array is copied. Write $${ for a literal ${.
+
+const (
+	placeholderStart     = "${"
+	placeholderEnd       = "}"
+	placeholderSeparator = ":"
+	placeholderEscape    = "$"
+)
+
+const (
+	EnvPlaceholder  = "env"
+	ConfPlaceholder = "conf"
+	FilePlaceholder = "file"
+)
+
+type PlaceholderError struct {
+	Path        string
+	Placeholder string
+	Reason      string
+}
+
+func (pe *PlaceholderError) Error() string {
+	return fmt.Sprintf("Unable to resolve placeholder %s at config path %s: %s", pe.Placeholder, pe.Path, pe.Reason)
+}
+
+type placeholderResolver struct {
+	ca        *ConfigAccessor
+	resolved  map[string]bool
+	resolving []string
+}
+
+func (ca *ConfigAccessor) ResolvePlaceholders() error {
+
+	r := &placeholderResolver{ca: ca, resolved: make(map[string]bool)}
+
+	_, err := r.resolveObject("", ca.JsonData)
+
+	return err
+}
+
+func (r *placeholderResolver) resolveObject(path string, object map[string]interface{}) (interface{}, error) {
+
+	keys := make([]string, 0, len(object))
+
+	for key := range object {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+
+		childPath := key
+
+		if path != "" {
+			childPath = path + JsonPathSeparator + key
+		}
+
+		if _, err := r.resolvePath(childPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return object, nil
+}
+
+func (r *placeholderResolver) resolvePath(path string) (interface{}, error) {
+
+	value := r.ca.Value(path)
+
+	if value == nil || r.resolved[path] {
+		return value, nil
+	}
+
+	for i, resolving := range r.resolving {
+
+		if resolving == path {
+			cycle := append(append([]string{}, r.resolving[i:]...), path)
+			return nil, &PlaceholderError{Reason: "circular reference " + strings.Join(cycle, " -> ")}
+		}
+	}
+
+	r.resolving = append(r.resolving, path)
+
+	resolved, err := r.resolveValue(path, value)
+
+	r.resolving = r.resolving[:len(r.resolving)-1]
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.ca.setValue(path, resolved); err != nil {
+		return nil, err
+	}
+
+	r.resolved[path] = true
+
+	return resolved, nil
+}
+
+func (r *placeholderResolver) resolveValue(path string, value interface{}) (interface{}, error) {
+
+	switch v := value.(type) {
+	case string:
+		return r.resolveString(path, v)
+	case map[string]interface{}:
+		return r.resolveObject(path, v)
+	case []interface{}:
+
+		for i, element := range v {
+
+			resolved, err := r.resolveValue(fmt.Sprintf("%s[%d]", path, i), element)
+
+			if err != nil {
+				return nil, err
+			}
+
+			v[i] = resolved
+		}
+
+		return v, nil
+	default:
+		return value, nil
+	}
+}
+
+func (r *placeholderResolver) resolveString(path string, s string) (interface{}, error) {
+
+	if !strings.Contains(s, placeholderStart) {
+		return s, nil
+	}
+
+	var b strings.Builder
+	remaining := s
+
+	for {
+
+		start := strings.Index(remaining, placeholderStart)
+
+		if start < 0 {
+			b.WriteString(remaining)
+			break
+		}
+
+		if strings.HasSuffix(remaining[:start], placeholderEscape) {
+			b.WriteString(remaining[:start-len(placeholderEscape)])
+			b.WriteString(placeholderStart)
+
+			remaining = remaining[start+len(placeholderStart):]
+			continue
+		}
+
+		end := strings.Index(remaining[start:], placeholderEnd)
+
+		if end < 0 {
+			return nil, &PlaceholderError{path, remaining[start:], "placeholder is not terminated with " + placeholderEnd}
+		}
+
+		placeholder := remaining[start : start+end+1]
+
+		value, err := r.resolvePlaceholder(path, placeholder)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if placeholder == s {
+			return value, nil
+		}
+
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, &PlaceholderError{path, placeholder, "an object or array cannot be embedded in a string"}
+		}
+
+		b.WriteString(remaining[:start])
+		b.WriteString(fmt.Sprint(value))
+
+		remaining = remaining[start+end+1:]
+	}
+
+	return b.String(), nil
+}
+
+func (r *placeholderResolver) resolvePlaceholder(path, placeholder string) (interface{}, error) {
+
+	body := strings.TrimSuffix(strings.TrimPrefix(placeholder, placeholderStart), placeholderEnd)
+	parts := strings.SplitN(body, placeholderSeparator, 3)
+
+	if len(parts) < 2 || parts[1] == "" {
+		return nil, &PlaceholderError{path, placeholder, "expected ${type:name} or ${type:name:default}"}
+	}
+
+	kind, name := parts[0], parts[1]
+	defaultValue, hasDefault := "", len(parts) == 3
+
+	if hasDefault {
+		defaultValue = parts[2]
+	}
+
+	var value interface{}
+	var reason string
+
+	switch kind {
+	case EnvPlaceholder:
+
+		if v, found := os.LookupEnv(name); found {
+			value = v
+		} else {
+			reason = fmt.Sprintf("environment variable %s is not set", name)
+		}
+
+	case ConfPlaceholder:
+
+		v, err := r.resolvePath(name)
+
+		if pe, found := err.(*PlaceholderError); found && pe.Placeholder == "" {
+			pe.Path = path
+			pe.Placeholder = placeholder
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if v != nil {
+			value = copyConfigValue(v)
+		} else {
+			reason = fmt.Sprintf("config path %s does not exist", name)
+		}
+
+	case FilePlaceholder:
+
+		if contents, err := ioutil.ReadFile(name); err == nil {
+			value = strings.TrimRight(string(contents), "\r\n")
+		} else {
+			reason = fmt.Sprintf("unable to read file %s: %s", name, err)
+		}
+
+	default:
+		return nil, &PlaceholderError{path, placeholder, fmt.Sprintf("unknown placeholder type %s (expected %s, %s or %s)", kind, EnvPlaceholder, ConfPlaceholder, FilePlaceholder)}
+	}
+
+	if value != nil {
+		return value, nil
+	}
+
+	if hasDefault {
+		return defaultValue, nil
+	}
+
+	return nil, &PlaceholderError{path, placeholder, reason}
+}
+
+func copyConfigValue(value interface{}) interface{} {
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+
+		for key, element := range v {
+			c[key] = copyConfigValue(element)
+		}
+
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+
+		for i, element := range v {
+			c[i] = copyConfigValue(element)
+		}
+
+		return c
+	default:
+		return value
+	}
+}
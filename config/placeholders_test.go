@@ -0,0 +1,99 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolvePlaceholders(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "placeholders")
+
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %s", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	secret := filepath.Join(dir, "db_password")
+	ioutil.WriteFile(secret, []byte("s3cret\n"), 0600)
+
+	os.Setenv("PLACEHOLDER_TEST_HOST", "db.internal")
+	defer os.Unsetenv("PLACEHOLDER_TEST_HOST")
+
+	jsonData := map[string]interface{}{
+		"RdbmsAccess": map[string]interface{}{
+			"Host":     "${env:PLACEHOLDER_TEST_HOST}",
+			"Port":     "${env:PLACEHOLDER_TEST_PORT:5432}",
+			"Password": "${file:" + secret + "}",
+			"Url":      "postgres://${conf:RdbmsAccess.Host}:${conf:RdbmsAccess.Port}/orders",
+		},
+		"Replica": "${conf:RdbmsAccess}",
+	}
+
+	ca, err := NewLayeredConfigAccessor(jsonData, nil)
+
+	if err != nil {
+		t.Fatalf("Unexpected error resolving placeholders: %s", err)
+	}
+
+	if url := ca.StringVal("RdbmsAccess.Url"); url != "postgres://db.internal:5432/orders" {
+		t.Errorf("Unexpected interpolated value %s", url)
+	}
+
+	if ca.StringVal("RdbmsAccess.Password") != "s3cret" || ca.IntValue("RdbmsAccess.Port") != 5432 {
+		t.Errorf("Expected file contents and a numeric default to be resolved")
+	}
+
+	if ca.StringVal("Replica.Host") != "db.internal" {
+		t.Errorf("Expected a whole object to be referenced")
+	}
+
+	ca.ObjectVal("Replica")["Host"] = "replica.internal"
+
+	if ca.StringVal("RdbmsAccess.Host") != "db.internal" {
+		t.Errorf("Expected a referenced object to be copied rather than shared")
+	}
+
+	cyclic := map[string]interface{}{"A": "${conf:B}", "B": "x-${conf:A}"}
+
+	_, err = NewLayeredConfigAccessor(cyclic, nil)
+
+	if pe, found := err.(*PlaceholderError); !found || !strings.Contains(pe.Reason, "circular reference") {
+		t.Errorf("Expected a circular reference to be reported, got %v", err)
+	}
+
+	_, err = NewLayeredConfigAccessor(map[string]interface{}{"Host": "${env:PLACEHOLDER_TEST_MISSING}"}, nil)
+
+	if pe, found := err.(*PlaceholderError); !found || pe.Path != "Host" || pe.Placeholder != "${env:PLACEHOLDER_TEST_MISSING}" {
+		t.Errorf("Expected the unresolved placeholder and its path to be reported, got %v", err)
+	}
+
+}
+
+func TestEscapedPlaceholders(t *testing.T) {
+
+	jsonData := map[string]interface{}{
+		"VarMatchRegEx": "$${([^}]*)}",
+		"Mixed":         "$${literal} and ${conf:Name}",
+		"Name":          "resolved",
+	}
+
+	ca, err := NewLayeredConfigAccessor(jsonData, nil)
+
+	if err != nil {
+		t.Fatalf("Unexpected error resolving placeholders: %s", err)
+	}
+
+	if v := ca.StringVal("VarMatchRegEx"); v != "${([^}]*)}" {
+		t.Errorf("Expected $${ to be read as a literal ${, got %s", v)
+	}
+
+	if v := ca.StringVal("Mixed"); v != "${literal} and resolved" {
+		t.Errorf("Expected escaped and resolved placeholders to be mixed, got %s", v)
+	}
+
+}
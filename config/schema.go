@@ -0,0 +1,289 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	SchemaAny = iota
+	SchemaString
+	SchemaInt
+	SchemaNumber
+	SchemaBool
+	SchemaDuration
+	SchemaObject
+	SchemaArray
+)
+
+type SchemaProvider interface {
+	ConfigSchemas() []*ConfigSchema
+}
+
+type ConfigSchema struct {
+	Path   string
+	fields map[string]*SchemaField
+}
+
+type SchemaField struct {
+	Name     string
+	Type     int
+	required bool
+	allowed  []interface{}
+	object   *ConfigSchema
+}
+
+type UnknownConfigKeyError struct {
+	Path       string
+	Suggestion string
+}
+
+func (uke *UnknownConfigKeyError) Error() string {
+
+	if uke.Suggestion == "" {
+		return fmt.Sprintf("Unknown configuration key %s", uke.Path)
+	}
+
+	return fmt.Sprintf("Unknown configuration key %s (did you mean %s?)", uke.Path, uke.Suggestion)
+}
+
+type SchemaErrors struct {
+	Problems []error
+}
+
+func (se *SchemaErrors) Error() string {
+
+	var b bytes.Buffer
+
+	b.WriteString(fmt.Sprintf("%d problem(s) found while validating configuration:", len(se.Problems)))
+
+	for _, problem := range se.Problems {
+		b.WriteString("\n  ")
+		b.WriteString(problem.Error())
+	}
+
+	return b.String()
+}
+
+func NewConfigSchema(path string) *ConfigSchema {
+	return &ConfigSchema{path, make(map[string]*SchemaField)}
+}
+
+func (cs *ConfigSchema) Field(name string, fieldType int) *SchemaField {
+
+	field := &SchemaField{Name: name, Type: fieldType}
+	cs.fields[name] = field
+
+	return field
+}
+
+func (cs *ConfigSchema) Object(name string) *ConfigSchema {
+
+	field := cs.Field(name, SchemaObject)
+	field.object = NewConfigSchema(cs.Path + JsonPathSeparator + name)
+
+	return field.object
+}
+
+func (sf *SchemaField) Required() *SchemaField {
+	sf.required = true
+	return sf
+}
+
+func (sf *SchemaField) Allowed(values ...interface{}) *SchemaField {
+	sf.allowed = values
+	return sf
+}
+
+func (cs *ConfigSchema) Validate(ca *ConfigAccessor) []error {
+
+	problems := []error{}
+
+	value := ca.Value(cs.Path)
+
+	if value == nil {
+		return cs.missingRequired(problems)
+	}
+
+	object, found := value.(map[string]interface{})
+
+	if !found {
+		return append(problems, typeError(cs.Path, "an object", value))
+	}
+
+	for _, key := range sortedObjectKeys(object) {
+
+		path := cs.Path + JsonPathSeparator + key
+
+		if cs.fields[key] == nil {
+			problems = append(problems, &UnknownConfigKeyError{path, cs.suggest(key)})
+		}
+	}
+
+	for _, name := range cs.fieldNames() {
+
+		field := cs.fields[name]
+		path := cs.Path + JsonPathSeparator + name
+		v, present := object[name]
+
+		if !present || v == nil {
+
+			if field.required {
+				problems = append(problems, &MissingConfigError{path})
+			}
+
+			continue
+		}
+
+		if err := field.check(path, v); err != nil {
+			problems = append(problems, err)
+			continue
+		}
+
+		if field.object != nil {
+			problems = append(problems, field.object.Validate(ca)...)
+		}
+	}
+
+	return problems
+}
+
+func (cs *ConfigSchema) missingRequired(problems []error) []error {
+
+	for _, name := range cs.fieldNames() {
+
+		if cs.fields[name].required {
+			problems = append(problems, &MissingConfigError{cs.Path + JsonPathSeparator + name})
+		}
+	}
+
+	return problems
+}
+
+func (cs *ConfigSchema) fieldNames() []string {
+
+	names := make([]string, 0, len(cs.fields))
+
+	for name := range cs.fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (cs *ConfigSchema) suggest(key string) string {
+
+	best := ""
+	bestDistance := len(key)/3 + 2
+
+	for _, name := range cs.fieldNames() {
+
+		if d := levenshtein(strings.ToLower(key), strings.ToLower(name)); d < bestDistance {
+			best = name
+			bestDistance = d
+		}
+	}
+
+	return best
+}
+
+func (sf *SchemaField) check(path string, v interface{}) error {
+
+	var err error
+
+	switch sf.Type {
+	case SchemaString:
+		_, err = toString(path, v)
+	case SchemaInt:
+		_, err = toInt64(path, v)
+	case SchemaNumber:
+		_, err = toFloat64(path, v)
+	case SchemaBool:
+		_, err = toBool(path, v)
+	case SchemaDuration:
+		_, err = toDuration(path, v)
+	case SchemaObject:
+		if _, found := v.(map[string]interface{}); !found {
+			err = typeError(path, "an object", v)
+		}
+	case SchemaArray:
+		if _, found := v.([]interface{}); !found {
+			err = typeError(path, "an array", v)
+		}
+	}
+
+	if err != nil || len(sf.allowed) == 0 {
+		return err
+	}
+
+	for _, a := range sf.allowed {
+
+		if strings.EqualFold(fmt.Sprint(a), fmt.Sprint(v)) {
+			return nil
+		}
+	}
+
+	return &ConfigTypeError{path, fmt.Sprintf("%v is not one of %v", v, sf.allowed)}
+}
+
+func sortedObjectKeys(object map[string]interface{}) []string {
+
+	keys := make([]string, 0, len(object))
+
+	for key := range object {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func levenshtein(a, b string) int {
+
+	ra, rb := []rune(a), []rune(b)
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+
+		current[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+
+			cost := 1
+
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func minInt(values ...int) int {
+
+	m := values[0]
+
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
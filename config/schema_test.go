@@ -0,0 +1,54 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestSchemaValidation(t *testing.T) {
+
+	ca := &ConfigAccessor{JsonData: map[string]interface{}{
+		"HttpServer": map[string]interface{}{
+			"Prot":        float64(8080),
+			"Encoding":    true,
+			"LogLevel":    "verbose",
+			"AccessLog":   map[string]interface{}{"UtcTime": true},
+			"Unrelated":   "x",
+			"ContentType": "application/json",
+		},
+	}}
+
+	s := NewConfigSchema("HttpServer")
+	s.Field("Port", SchemaInt).Required()
+	s.Field("Encoding", SchemaString)
+	s.Field("ContentType", SchemaString)
+	s.Field("LogLevel", SchemaString).Allowed("INFO", "DEBUG")
+	s.Object("AccessLog").Field("UtcTimes", SchemaBool)
+
+	problems := s.Validate(ca)
+
+	expected := []string{
+		"Unknown configuration key HttpServer.AccessLog.UtcTime (did you mean UtcTimes?)",
+		"Unknown configuration key HttpServer.Prot (did you mean Port?)",
+		"Unknown configuration key HttpServer.Unrelated",
+		"Unable to use the configuration at path HttpServer.Encoding: expected a string, found bool",
+		"Unable to use the configuration at path HttpServer.LogLevel: verbose is not one of [INFO DEBUG]",
+		"No configuration found at path HttpServer.Port",
+	}
+
+	found := make(map[string]bool)
+
+	for _, p := range problems {
+		found[p.Error()] = true
+	}
+
+	for _, e := range expected {
+		if !found[e] {
+			t.Errorf("Expected problem %q, got %v", e, problems)
+		}
+	}
+
+	if len(problems) != len(expected) {
+		t.Errorf("Expected %d problems, got %d", len(expected), len(problems))
+	}
+
+}
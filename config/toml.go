@@ -0,0 +1,691 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var tomlDate = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+var tomlDateTime = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?([Zz]|[+-]\d{2}:\d{2})?)?$|^\d{2}:\d{2}:\d{2}(\.\d+)?$`)
+
+type TomlDecoder struct {
+}
+
+func (td *TomlDecoder) Decode(data []byte) (map[string]interface{}, error) {
+
+	p := &tomlParser{s: strings.Replace(string(data), "\r\n", "\n", -1), line: 1}
+	p.root = make(map[string]interface{})
+	p.current = p.root
+	p.defined = make(map[string]string)
+
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+
+	return p.root, nil
+}
+
+type tomlParser struct {
+	s       string
+	pos     int
+	line    int
+	root    map[string]interface{}
+	current map[string]interface{}
+	defined map[string]string
+}
+
+// Definitions are recorded against the table holding a key rather than the key's
+// path, so each table in an array of tables has its own set of definitions.
+const (
+	tomlTable      = "table"
+	tomlInline     = "inline"
+	tomlArrayTable = "array"
+)
+
+func tomlDefinition(parent map[string]interface{}, key string) string {
+	return fmt.Sprintf("%p.%s", parent, key)
+}
+
+func (p *tomlParser) error(reason string) error {
+	return &DecodeError{p.line, reason}
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *tomlParser) peekIs(prefix string) bool {
+	return strings.HasPrefix(p.s[p.pos:], prefix)
+}
+
+func (p *tomlParser) skipSpace() {
+
+	for !p.eof() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+
+	if !p.eof() && p.s[p.pos] == '#' {
+
+		for !p.eof() && p.s[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) skipBlank() {
+
+	for {
+		p.skipSpace()
+		p.skipComment()
+
+		if p.eof() || p.s[p.pos] != '\n' {
+			return
+		}
+
+		p.pos++
+		p.line++
+	}
+}
+
+func (p *tomlParser) endOfLine() error {
+
+	p.skipSpace()
+	p.skipComment()
+
+	if p.eof() {
+		return nil
+	}
+
+	if p.s[p.pos] != '\n' {
+		return p.error(fmt.Sprintf("unexpected %q, expected the end of the line", p.s[p.pos]))
+	}
+
+	return nil
+}
+
+func (p *tomlParser) parse() error {
+
+	for {
+
+		p.skipBlank()
+
+		if p.eof() {
+			return nil
+		}
+
+		var err error
+
+		if p.peekIs("[[") {
+			err = p.parseArrayTable()
+		} else if p.peekIs("[") {
+			err = p.parseTable()
+		} else {
+			err = p.parseKeyValue(p.current)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) parseTable() error {
+
+	p.pos++
+
+	keys, err := p.parseKeyPath()
+
+	if err != nil {
+		return err
+	}
+
+	if !p.peekIs("]") {
+		return p.error("expected ] to close the table header")
+	}
+
+	p.pos++
+
+	name := strings.Join(keys, JsonPathSeparator)
+
+	parent, err := p.navigate(p.root, keys[:len(keys)-1])
+
+	if err != nil {
+		return err
+	}
+
+	last := keys[len(keys)-1]
+	definition := tomlDefinition(parent, last)
+
+	switch p.defined[definition] {
+	case tomlTable:
+		return p.error(fmt.Sprintf("table %s is defined more than once", name))
+	case tomlInline:
+		return p.error(fmt.Sprintf("%s is defined inline and cannot be extended", name))
+	case tomlArrayTable:
+		return p.error(fmt.Sprintf("%s is an array of tables and cannot be defined as a table", name))
+	}
+
+	table, err := p.navigate(parent, keys[len(keys)-1:])
+
+	if err != nil {
+		return err
+	}
+
+	p.defined[definition] = tomlTable
+	p.current = table
+
+	return nil
+}
+
+func (p *tomlParser) parseArrayTable() error {
+
+	p.pos += 2
+
+	keys, err := p.parseKeyPath()
+
+	if err != nil {
+		return err
+	}
+
+	if !p.peekIs("]]") {
+		return p.error("expected ]] to close the array of tables header")
+	}
+
+	p.pos += 2
+
+	parent, err := p.navigate(p.root, keys[:len(keys)-1])
+
+	if err != nil {
+		return err
+	}
+
+	last := keys[len(keys)-1]
+	definition := tomlDefinition(parent, last)
+	table := make(map[string]interface{})
+
+	switch existing := parent[last].(type) {
+	case nil:
+		parent[last] = []interface{}{table}
+		p.defined[definition] = tomlArrayTable
+	case []interface{}:
+
+		if p.defined[definition] != tomlArrayTable {
+			return p.error(fmt.Sprintf("%s is defined inline and cannot be extended", last))
+		}
+
+		parent[last] = append(existing, table)
+	default:
+		return p.error(fmt.Sprintf("%s is already defined and is not an array of tables", last))
+	}
+
+	p.current = table
+
+	return nil
+}
+
+func (p *tomlParser) navigate(table map[string]interface{}, keys []string) (map[string]interface{}, error) {
+
+	for _, key := range keys {
+
+		if p.defined[tomlDefinition(table, key)] == tomlInline {
+			return nil, p.error(fmt.Sprintf("%s is defined inline and cannot be extended", key))
+		}
+
+		switch next := table[key].(type) {
+		case nil:
+			created := make(map[string]interface{})
+			table[key] = created
+			table = created
+		case map[string]interface{}:
+			table = next
+		case []interface{}:
+
+			if len(next) == 0 {
+				return nil, p.error(fmt.Sprintf("%s is an array and not a table", key))
+			}
+
+			last, isTable := next[len(next)-1].(map[string]interface{})
+
+			if !isTable {
+				return nil, p.error(fmt.Sprintf("%s is an array and not a table", key))
+			}
+
+			table = last
+		default:
+			return nil, p.error(fmt.Sprintf("%s is already defined as a value", key))
+		}
+	}
+
+	return table, nil
+}
+
+func (p *tomlParser) parseKeyValue(table map[string]interface{}) error {
+
+	keys, err := p.parseKeyPath()
+
+	if err != nil {
+		return err
+	}
+
+	if !p.peekIs("=") {
+		return p.error(fmt.Sprintf("expected = after key %s", strings.Join(keys, JsonPathSeparator)))
+	}
+
+	p.pos++
+
+	value, err := p.parseValue()
+
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.navigate(table, keys[:len(keys)-1])
+
+	if err != nil {
+		return err
+	}
+
+	last := keys[len(keys)-1]
+
+	if _, exists := parent[last]; exists {
+		return p.error(fmt.Sprintf("key %s is defined more than once", strings.Join(keys, JsonPathSeparator)))
+	}
+
+	parent[last] = value
+
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		p.defined[tomlDefinition(parent, last)] = tomlInline
+	}
+
+	return nil
+}
+
+func (p *tomlParser) parseKeyPath() ([]string, error) {
+
+	keys := []string{}
+
+	for {
+
+		p.skipSpace()
+
+		key, err := p.parseKey()
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+
+		p.skipSpace()
+
+		if !p.peekIs(".") {
+			return keys, nil
+		}
+
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseKey() (string, error) {
+
+	if p.peekIs("\"") {
+		return p.parseBasicString()
+	}
+
+	if p.peekIs("'") {
+		return p.parseLiteralString()
+	}
+
+	start := p.pos
+
+	for !p.eof() && isTomlBareKeyChar(p.s[p.pos]) {
+		p.pos++
+	}
+
+	if start == p.pos {
+		return "", p.error("expected a key")
+	}
+
+	return p.s[start:p.pos], nil
+}
+
+func isTomlBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+
+	p.skipSpace()
+
+	if p.eof() {
+		return nil, p.error("expected a value")
+	}
+
+	switch {
+	case p.peekIs(`"""`):
+		return p.parseMultiLineString(`"""`)
+	case p.peekIs("'''"):
+		return p.parseMultiLineString("'''")
+	case p.peekIs("\""):
+		return p.parseBasicString()
+	case p.peekIs("'"):
+		return p.parseLiteralString()
+	case p.peekIs("["):
+		return p.parseArray()
+	case p.peekIs("{"):
+		return p.parseInlineTable()
+	}
+
+	start := p.pos
+
+	for !p.eof() && !strings.ContainsRune(" \t\n,]}#", rune(p.s[p.pos])) {
+		p.pos++
+	}
+
+	token := p.s[start:p.pos]
+
+	if tomlDate.MatchString(token) && p.peekIs(" ") {
+
+		rest := p.s[p.pos+1:]
+		end := strings.IndexAny(rest, " \t\n,]}#")
+
+		if end < 0 {
+			end = len(rest)
+		}
+
+		if full := token + " " + rest[:end]; tomlDateTime.MatchString(full) {
+			p.pos += 1 + end
+			return full, nil
+		}
+	}
+
+	if tomlDateTime.MatchString(token) {
+		return token, nil
+	}
+
+	return p.parseScalar(token)
+}
+
+func (p *tomlParser) parseScalar(token string) (interface{}, error) {
+
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "inf", "+inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	case "nan", "+nan", "-nan":
+		return math.NaN(), nil
+	}
+
+	number := strings.Replace(token, "_", "", -1)
+
+	if strings.HasPrefix(number, "0x") || strings.HasPrefix(number, "0o") || strings.HasPrefix(number, "0b") {
+
+		if i, err := strconv.ParseInt(number, 0, 64); err == nil {
+			return float64(i), nil
+		}
+	}
+
+	if yamlNumber.MatchString(number) {
+
+		if f, err := strconv.ParseFloat(number, 64); err == nil {
+			return f, nil
+		}
+	}
+
+	return nil, p.error(fmt.Sprintf("invalid value %s", token))
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+
+	p.pos++
+
+	var b strings.Builder
+
+	for {
+
+		if p.eof() || p.s[p.pos] == '\n' {
+			return "", p.error("unterminated string")
+		}
+
+		c := p.s[p.pos]
+
+		if c == '"' {
+			p.pos++
+			return b.String(), nil
+		}
+
+		if c == '\\' {
+
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+
+			continue
+		}
+
+		b.WriteByte(c)
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+
+	p.pos++
+
+	if p.eof() {
+		return p.error("unterminated escape sequence")
+	}
+
+	c := p.s[p.pos]
+	p.pos++
+
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+
+		length := 4
+
+		if c == 'U' {
+			length = 8
+		}
+
+		if p.pos+length > len(p.s) {
+			return p.error("truncated unicode escape")
+		}
+
+		code, err := strconv.ParseUint(p.s[p.pos:p.pos+length], 16, 32)
+
+		if err != nil {
+			return p.error(fmt.Sprintf("invalid unicode escape \\%c%s", c, p.s[p.pos:p.pos+length]))
+		}
+
+		b.WriteRune(rune(code))
+		p.pos += length
+	default:
+		return p.error(fmt.Sprintf("invalid escape sequence \\%c", c))
+	}
+
+	return nil
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+
+	p.pos++
+	start := p.pos
+
+	for !p.eof() && p.s[p.pos] != '\'' {
+
+		if p.s[p.pos] == '\n' {
+			return "", p.error("unterminated string")
+		}
+
+		p.pos++
+	}
+
+	if p.eof() {
+		return "", p.error("unterminated string")
+	}
+
+	value := p.s[start:p.pos]
+	p.pos++
+
+	return value, nil
+}
+
+func (p *tomlParser) parseMultiLineString(delimiter string) (string, error) {
+
+	p.pos += len(delimiter)
+
+	if p.peekIs("\n") {
+		p.pos++
+		p.line++
+	}
+
+	var b strings.Builder
+
+	for {
+
+		if p.eof() {
+			return "", p.error("unterminated multi-line string")
+		}
+
+		if p.peekIs(delimiter) {
+			p.pos += len(delimiter)
+			return b.String(), nil
+		}
+
+		c := p.s[p.pos]
+
+		if c == '\\' && delimiter == `"""` {
+
+			rest := strings.TrimLeft(p.s[p.pos+1:], " \t")
+
+			if strings.HasPrefix(rest, "\n") {
+
+				p.pos = len(p.s) - len(rest)
+
+				for !p.eof() && strings.ContainsRune(" \t\n", rune(p.s[p.pos])) {
+
+					if p.s[p.pos] == '\n' {
+						p.line++
+					}
+
+					p.pos++
+				}
+
+				continue
+			}
+
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+
+			continue
+		}
+
+		if c == '\n' {
+			p.line++
+		}
+
+		b.WriteByte(c)
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseArray() (interface{}, error) {
+
+	p.pos++
+	a := []interface{}{}
+
+	for {
+
+		p.skipBlank()
+
+		if p.eof() {
+			return nil, p.error("unterminated array")
+		}
+
+		if p.peekIs("]") {
+			p.pos++
+			return a, nil
+		}
+
+		value, err := p.parseValue()
+
+		if err != nil {
+			return nil, err
+		}
+
+		a = append(a, value)
+
+		p.skipBlank()
+
+		if p.peekIs(",") {
+			p.pos++
+		} else if !p.peekIs("]") {
+			return nil, p.error("expected , or ] in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+
+	p.pos++
+	table := make(map[string]interface{})
+
+	p.skipSpace()
+
+	if p.peekIs("}") {
+		p.pos++
+		return table, nil
+	}
+
+	for {
+
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+
+		if p.peekIs("}") {
+			p.pos++
+			return table, nil
+		}
+
+		if !p.peekIs(",") {
+			return nil, p.error("expected , or } in inline table")
+		}
+
+		p.pos++
+	}
+}
@@ -0,0 +1,400 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+func (c *ConfigAccessor) String(path string) (string, error) {
+
+	v, err := c.required(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	return toString(path, v)
+}
+
+func (c *ConfigAccessor) Int(path string) (int, error) {
+
+	v, err := c.required(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return toInt(path, v)
+}
+
+func (c *ConfigAccessor) Int64(path string) (int64, error) {
+
+	v, err := c.required(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return toInt64(path, v)
+}
+
+func (c *ConfigAccessor) Uint(path string) (uint, error) {
+
+	i, err := c.Int64(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if i < 0 {
+		return 0, &ConfigTypeError{path, fmt.Sprintf("%d is negative", i)}
+	}
+
+	return uint(i), nil
+}
+
+func (c *ConfigAccessor) Float64(path string) (float64, error) {
+
+	v, err := c.required(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return toFloat64(path, v)
+}
+
+func (c *ConfigAccessor) Bool(path string) (bool, error) {
+
+	v, err := c.required(path)
+
+	if err != nil {
+		return false, err
+	}
+
+	return toBool(path, v)
+}
+
+func (c *ConfigAccessor) Duration(path string) (time.Duration, error) {
+
+	v, err := c.required(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return toDuration(path, v)
+}
+
+func (c *ConfigAccessor) Object(path string) (map[string]interface{}, error) {
+
+	v, err := c.required(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	o, found := v.(map[string]interface{})
+
+	if !found {
+		return nil, typeError(path, "an object", v)
+	}
+
+	return o, nil
+}
+
+func (c *ConfigAccessor) StringArray(path string) ([]string, error) {
+
+	a, err := c.array(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := make([]string, len(a))
+
+	for i, v := range a {
+		if s[i], err = toString(elementPath(path, i), v); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (c *ConfigAccessor) IntArray(path string) ([]int, error) {
+
+	a, err := c.array(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ints := make([]int, len(a))
+
+	for i, v := range a {
+
+		n, err := toInt(elementPath(path, i), v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ints[i] = n
+	}
+
+	return ints, nil
+}
+
+func (c *ConfigAccessor) Float64Array(path string) ([]float64, error) {
+
+	a, err := c.array(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	f := make([]float64, len(a))
+
+	for i, v := range a {
+		if f[i], err = toFloat64(elementPath(path, i), v); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func (c *ConfigAccessor) BoolArray(path string) ([]bool, error) {
+
+	a, err := c.array(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]bool, len(a))
+
+	for i, v := range a {
+		if b[i], err = toBool(elementPath(path, i), v); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func (c *ConfigAccessor) StringOrDefault(path string, d string) (string, error) {
+
+	if !c.PathExists(path) {
+		return d, nil
+	}
+
+	return c.String(path)
+}
+
+func (c *ConfigAccessor) IntOrDefault(path string, d int) (int, error) {
+
+	if !c.PathExists(path) {
+		return d, nil
+	}
+
+	return c.Int(path)
+}
+
+func (c *ConfigAccessor) Int64OrDefault(path string, d int64) (int64, error) {
+
+	if !c.PathExists(path) {
+		return d, nil
+	}
+
+	return c.Int64(path)
+}
+
+func (c *ConfigAccessor) UintOrDefault(path string, d uint) (uint, error) {
+
+	if !c.PathExists(path) {
+		return d, nil
+	}
+
+	return c.Uint(path)
+}
+
+func (c *ConfigAccessor) Float64OrDefault(path string, d float64) (float64, error) {
+
+	if !c.PathExists(path) {
+		return d, nil
+	}
+
+	return c.Float64(path)
+}
+
+func (c *ConfigAccessor) BoolOrDefault(path string, d bool) (bool, error) {
+
+	if !c.PathExists(path) {
+		return d, nil
+	}
+
+	return c.Bool(path)
+}
+
+func (c *ConfigAccessor) DurationOrDefault(path string, d time.Duration) (time.Duration, error) {
+
+	if !c.PathExists(path) {
+		return d, nil
+	}
+
+	return c.Duration(path)
+}
+
+func (c *ConfigAccessor) ObjectOrDefault(path string, d map[string]interface{}) (map[string]interface{}, error) {
+
+	if !c.PathExists(path) {
+		return d, nil
+	}
+
+	return c.Object(path)
+}
+
+func (c *ConfigAccessor) required(path string) (interface{}, error) {
+
+	v := c.Value(path)
+
+	if v == nil {
+		return nil, &MissingConfigError{path}
+	}
+
+	return v, nil
+}
+
+func (c *ConfigAccessor) array(path string) ([]interface{}, error) {
+
+	v, err := c.required(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	a, found := v.([]interface{})
+
+	if !found {
+		return nil, typeError(path, "an array", v)
+	}
+
+	return a, nil
+}
+
+func toString(path string, v interface{}) (string, error) {
+
+	s, found := v.(string)
+
+	if !found {
+		return "", typeError(path, "a string", v)
+	}
+
+	return s, nil
+}
+
+func toFloat64(path string, v interface{}) (float64, error) {
+
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+
+		if err != nil {
+			return 0, &ConfigTypeError{path, fmt.Sprintf("%q is not a number", n)}
+		}
+
+		return f, nil
+	default:
+		return 0, typeError(path, "a number", v)
+	}
+}
+
+func toInt(path string, v interface{}) (int, error) {
+
+	i, err := toInt64(path, v)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if int64(int(i)) != i {
+		return 0, &ConfigTypeError{path, fmt.Sprintf("%d is too large for an int", i)}
+	}
+
+	return int(i), nil
+}
+
+func toInt64(path string, v interface{}) (int64, error) {
+
+	if s, found := v.(string); found {
+
+		i, err := strconv.ParseInt(s, 10, 64)
+
+		if err != nil {
+			return 0, &ConfigTypeError{path, fmt.Sprintf("%q is not an integer", s)}
+		}
+
+		return i, nil
+	}
+
+	f, err := toFloat64(path, v)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if f != math.Trunc(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, &ConfigTypeError{path, fmt.Sprintf("%v is not an integer", f)}
+	}
+
+	return int64(f), nil
+}
+
+func toBool(path string, v interface{}) (bool, error) {
+
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		parsed, err := strconv.ParseBool(b)
+
+		if err != nil {
+			return false, &ConfigTypeError{path, fmt.Sprintf("%q is not a bool", b)}
+		}
+
+		return parsed, nil
+	default:
+		return false, typeError(path, "a bool", v)
+	}
+}
+
+func toDuration(path string, v interface{}) (time.Duration, error) {
+
+	s, err := toString(path, v)
+
+	if err != nil {
+		return 0, typeError(path, "a duration string such as \"30s\"", v)
+	}
+
+	d, err := time.ParseDuration(s)
+
+	if err != nil {
+		return 0, &ConfigTypeError{path, fmt.Sprintf("%q is not a duration: %s", s, err)}
+	}
+
+	return d, nil
+}
+
+func typeError(path string, expected string, found interface{}) error {
+	return &ConfigTypeError{path, fmt.Sprintf("expected %s, found %T", expected, found)}
+}
+
+func elementPath(path string, index int) string {
+	return fmt.Sprintf("%s[%d]", path, index)
+}
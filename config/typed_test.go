@@ -0,0 +1,87 @@
+package config
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+type typedTarget struct {
+	Timeout time.Duration
+	Limit   uint
+	Ratio   float64
+	Hosts   []string
+	Ports   []int
+	Offset  int64
+}
+
+func TestTypedGetters(t *testing.T) {
+
+	ca := &ConfigAccessor{JsonData: map[string]interface{}{
+		"Server": map[string]interface{}{
+			"Timeout": "30s",
+			"Limit":   float64(10),
+			"Ratio":   0.5,
+			"Hosts":   []interface{}{"a", "b"},
+			"Ports":   []interface{}{float64(80), "443"},
+			"Offset":  float64(-3),
+			"Name":    float64(1),
+		},
+	}}
+
+	if d, err := ca.Duration("Server.Timeout"); err != nil || d != 30*time.Second {
+		t.Errorf("Expected a 30s duration, got %v %v", d, err)
+	}
+
+	if _, err := ca.String("Server.Missing"); err == nil {
+		t.Errorf("Expected a missing path to be reported")
+	} else if _, found := err.(*MissingConfigError); !found {
+		t.Errorf("Expected a MissingConfigError, got %T", err)
+	}
+
+	if _, err := ca.String("Server.Name"); err == nil {
+		t.Errorf("Expected a type mismatch to be reported")
+	}
+
+	if v, err := ca.IntOrDefault("Server.Missing", 7); err != nil || v != 7 {
+		t.Errorf("Expected the default to be returned for a missing path")
+	}
+
+	if _, err := ca.Uint("Server.Offset"); err == nil {
+		t.Errorf("Expected a negative value to be rejected as a uint")
+	}
+
+	target := new(typedTarget)
+
+	if err := ca.Populate("Server", target); err != nil {
+		t.Fatalf("Unexpected error populating typed fields: %s", err)
+	}
+
+	if target.Timeout != 30*time.Second || target.Limit != 10 || target.Ratio != 0.5 || target.Offset != -3 {
+		t.Errorf("Unexpected scalar values %+v", target)
+	}
+
+	if len(target.Hosts) != 2 || target.Ports[1] != 443 {
+		t.Errorf("Unexpected array values %+v", target)
+	}
+
+}
+
+func TestIntegerOverflowRejected(t *testing.T) {
+
+	ca := &ConfigAccessor{JsonData: map[string]interface{}{
+		"Limits": map[string]interface{}{
+			"Max":   float64(math.MaxInt64),
+			"Sizes": []interface{}{float64(1), float64(math.MaxInt64)},
+		},
+	}}
+
+	if _, err := ca.Int64("Limits.Max"); err == nil {
+		t.Errorf("Expected 2^63 to be rejected as an int64")
+	}
+
+	if _, err := ca.IntArray("Limits.Sizes"); err == nil {
+		t.Errorf("Expected an out of range element to be rejected")
+	}
+
+}
@@ -0,0 +1,936 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var yamlNumber = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+
+// YamlDecoder decodes the subset of YAML used for configuration: a single document
+// of block and flow mappings and sequences holding plain, quoted and block scalars.
+// Anchors, aliases, tags, complex keys and multiple documents are not supported and
+// are reported as such. Plain mapping keys are used as written, so 1.0: is the key
+// "1.0"; null keys are rejected.
+type YamlDecoder struct {
+}
+
+func (yd *YamlDecoder) Decode(data []byte) (map[string]interface{}, error) {
+
+	p := newYamlParser(string(data))
+
+	decoded, err := p.parseDocument()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if decoded == nil {
+		return make(map[string]interface{}), nil
+	}
+
+	object, found := decoded.(map[string]interface{})
+
+	if !found {
+		return nil, fmt.Errorf("expected a mapping at the top level, found %T", decoded)
+	}
+
+	return object, nil
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines      []*yamlLine
+	pos        int
+	docStarted bool
+}
+
+func newYamlParser(s string) *yamlParser {
+
+	p := new(yamlParser)
+
+	s = strings.TrimSuffix(strings.Replace(s, "\r\n", "\n", -1), "\n")
+
+	for i, raw := range strings.Split(s, "\n") {
+
+		trimmed := strings.TrimLeft(raw, " ")
+		p.lines = append(p.lines, &yamlLine{i + 1, len(raw) - len(trimmed), strings.TrimRight(trimmed, " \t")})
+	}
+
+	return p
+}
+
+func (p *yamlParser) peek() (*yamlLine, error) {
+
+	for ; p.pos < len(p.lines); p.pos++ {
+
+		l := p.lines[p.pos]
+
+		if l.text == "" || strings.HasPrefix(l.text, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(l.text, "\t") {
+			return nil, &DecodeError{l.number, "tabs cannot be used for indentation"}
+		}
+
+		if l.indent == 0 && (l.text == "---" || strings.HasPrefix(l.text, "--- ")) {
+
+			if p.docStarted {
+				return nil, &DecodeError{l.number, "multiple documents are not supported"}
+			}
+
+			p.docStarted = true
+			continue
+		}
+
+		if l.indent == 0 && l.text == "..." {
+			continue
+		}
+
+		p.docStarted = true
+
+		return l, nil
+	}
+
+	return nil, nil
+}
+
+func (p *yamlParser) parseDocument() (interface{}, error) {
+
+	l, err := p.peek()
+
+	if l == nil || err != nil {
+		return nil, err
+	}
+
+	value, err := p.parseBlock(l.indent)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if l, err = p.peek(); l != nil {
+		return nil, &DecodeError{l.number, "unexpected content after the end of the document"}
+	}
+
+	return value, err
+}
+
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+
+	l, err := p.peek()
+
+	if l == nil || err != nil {
+		return nil, err
+	}
+
+	if isYamlSequenceItem(l.text) {
+		return p.parseSequence(indent)
+	}
+
+	if _, _, isEntry := splitYamlKey(stripYamlComment(l.text)); isEntry {
+		return p.parseMapping(indent)
+	}
+
+	p.pos++
+
+	return p.parseValue(indent-1, l.text, l, false)
+}
+
+func (p *yamlParser) parseMapping(indent int) (map[string]interface{}, error) {
+
+	m := make(map[string]interface{})
+
+	for {
+
+		l, err := p.peek()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if l == nil || l.indent < indent {
+			return m, nil
+		}
+
+		if l.indent > indent {
+			return nil, &DecodeError{l.number, "unexpected indentation"}
+		}
+
+		rawKey, rest, isEntry := splitYamlKey(stripYamlComment(l.text))
+
+		if !isEntry {
+
+			if isYamlSequenceItem(l.text) {
+				return m, nil
+			}
+
+			return nil, &DecodeError{l.number, "expected 'key: value'"}
+		}
+
+		key, err := yamlKey(rawKey, l.number)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, duplicate := m[key]; duplicate {
+			return nil, &DecodeError{l.number, fmt.Sprintf("duplicate key %s", key)}
+		}
+
+		p.pos++
+
+		value, err := p.parseValue(indent, rest, l, true)
+
+		if err != nil {
+			return nil, err
+		}
+
+		m[key] = value
+	}
+}
+
+func (p *yamlParser) parseSequence(indent int) ([]interface{}, error) {
+
+	s := []interface{}{}
+
+	for {
+
+		l, err := p.peek()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if l == nil || l.indent < indent || !isYamlSequenceItem(l.text) {
+			return s, nil
+		}
+
+		if l.indent > indent {
+			return nil, &DecodeError{l.number, "unexpected indentation"}
+		}
+
+		p.pos++
+
+		content := strings.TrimLeft(l.text[1:], " ")
+		contentIndent := l.indent + len(l.text) - len(content)
+
+		var value interface{}
+
+		_, _, isEntry := splitYamlKey(stripYamlComment(content))
+
+		if isEntry || isYamlSequenceItem(content) {
+			p.pos--
+			p.lines[p.pos] = &yamlLine{l.number, contentIndent, content}
+			value, err = p.parseBlock(contentIndent)
+		} else {
+			value, err = p.parseValue(indent, content, l, false)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		s = append(s, value)
+	}
+}
+
+func (p *yamlParser) parseValue(indent int, rest string, l *yamlLine, compactSequence bool) (interface{}, error) {
+
+	if rest != "" && (rest[0] == '"' || rest[0] == '\'') && closingQuote(rest, 0) < 0 {
+
+		joined, err := p.quotedContinuation(rest, l)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rest = joined
+	}
+
+	value := stripYamlComment(rest)
+
+	if value == "" {
+
+		next, err := p.peek()
+
+		if next == nil || err != nil {
+			return nil, err
+		}
+
+		if next.indent > indent {
+			return p.parseBlock(next.indent)
+		}
+
+		if compactSequence && next.indent == indent && isYamlSequenceItem(next.text) {
+			return p.parseSequence(indent)
+		}
+
+		return nil, nil
+	}
+
+	switch value[0] {
+	case '|', '>':
+		return p.parseBlockScalar(indent, value, l)
+	case '&', '*', '!':
+		return nil, &DecodeError{l.number, "anchors, aliases and tags are not supported"}
+	case '[', '{':
+		value, err := p.flowContinuation(value, l)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fp := &yamlFlowParser{value, 0, l.number}
+		return fp.parseDocument()
+	case '"', '\'':
+		return parseYamlScalar(value, l.number)
+	}
+
+	if value != rest {
+		return parseYamlScalar(value, l.number)
+	}
+
+	value, err := p.plainContinuation(indent, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseYamlScalar(value, l.number)
+}
+
+func (p *yamlParser) plainContinuation(indent int, value string) (string, error) {
+
+	var b strings.Builder
+	b.WriteString(value)
+
+	blank := 0
+
+	for i := p.pos; i < len(p.lines); i++ {
+
+		line := p.lines[i]
+
+		if line.text == "" {
+			blank++
+			continue
+		}
+
+		if line.indent <= indent || strings.HasPrefix(line.text, "#") {
+			break
+		}
+
+		text := stripYamlComment(line.text)
+
+		if _, _, isEntry := splitYamlKey(text); isEntry {
+			return "", &DecodeError{line.number, "unexpected indentation"}
+		}
+
+		if blank > 0 {
+			b.WriteString(strings.Repeat("\n", blank))
+		} else {
+			b.WriteString(" ")
+		}
+
+		b.WriteString(text)
+
+		blank = 0
+		p.pos = i + 1
+
+		if text != line.text {
+			break
+		}
+	}
+
+	return b.String(), nil
+}
+
+func (p *yamlParser) quotedContinuation(first string, l *yamlLine) (string, error) {
+
+	var b strings.Builder
+	b.WriteString(first)
+
+	blank := 0
+
+	for ; p.pos < len(p.lines); p.pos++ {
+
+		text := strings.TrimLeft(p.lines[p.pos].text, " \t")
+
+		if text == "" {
+			blank++
+			continue
+		}
+
+		joined := b.String()
+
+		switch {
+		case blank > 0:
+			b.WriteString(strings.Repeat("\n", blank))
+		case first[0] == '"' && escapedLineBreak(joined):
+			b.Reset()
+			b.WriteString(joined[:len(joined)-1])
+		default:
+			b.WriteString(" ")
+		}
+
+		b.WriteString(text)
+		blank = 0
+
+		if closingQuote(b.String(), 0) >= 0 {
+			p.pos++
+			return b.String(), nil
+		}
+	}
+
+	return "", &DecodeError{l.number, fmt.Sprintf("unterminated string %s", first)}
+}
+
+func escapedLineBreak(s string) bool {
+
+	backslashes := 0
+
+	for i := len(s) - 1; i >= 0 && s[i] == '\\'; i-- {
+		backslashes++
+	}
+
+	return backslashes%2 == 1
+}
+
+func (p *yamlParser) flowContinuation(value string, l *yamlLine) (string, error) {
+
+	var b strings.Builder
+	b.WriteString(value)
+
+	for !flowComplete(b.String()) {
+
+		if p.pos >= len(p.lines) {
+			return "", &DecodeError{l.number, "unterminated flow collection"}
+		}
+
+		text := stripYamlComment(strings.TrimLeft(p.lines[p.pos].text, " \t"))
+		p.pos++
+
+		if text != "" {
+			b.WriteString(" ")
+			b.WriteString(text)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func flowComplete(s string) bool {
+
+	depth := 0
+
+	for i := 0; i < len(s); i++ {
+
+		switch s[i] {
+		case '"', '\'':
+			if i == 0 || strings.IndexByte(" \t[{,:", s[i-1]) >= 0 {
+
+				end := closingQuote(s, i)
+
+				if end < 0 {
+					return false
+				}
+
+				i = end
+			}
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+	}
+
+	return depth <= 0
+}
+
+func (p *yamlParser) parseBlockScalar(indent int, header string, l *yamlLine) (interface{}, error) {
+
+	folded := header[0] == '>'
+	chomping := strings.TrimSpace(header[1:])
+
+	if chomping != "" && chomping != "-" && chomping != "+" {
+		return nil, &DecodeError{l.number, fmt.Sprintf("unsupported block scalar header %s", header)}
+	}
+
+	contentIndent := -1
+	lines := []string{}
+
+	for ; p.pos < len(p.lines); p.pos++ {
+
+		line := p.lines[p.pos]
+
+		if line.text == "" {
+			lines = append(lines, "")
+			continue
+		}
+
+		if line.indent <= indent {
+			break
+		}
+
+		if contentIndent < 0 {
+			contentIndent = line.indent
+		}
+
+		if line.indent < contentIndent {
+			return nil, &DecodeError{line.number, "block scalar line is less indented than the first line"}
+		}
+
+		lines = append(lines, strings.Repeat(" ", line.indent-contentIndent)+line.text)
+	}
+
+	trailing := 0
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var b strings.Builder
+
+	for i, line := range lines {
+
+		switch {
+		case i == 0:
+		case !folded || strings.HasPrefix(line, " ") || strings.HasPrefix(lines[i-1], " "):
+			b.WriteString("\n")
+		case line == "":
+			b.WriteString("\n")
+		case lines[i-1] != "":
+			b.WriteString(" ")
+		}
+
+		b.WriteString(line)
+	}
+
+	switch chomping {
+	case "-":
+	case "+":
+		b.WriteString(strings.Repeat("\n", trailing+1))
+	default:
+		if len(lines) > 0 {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String(), nil
+}
+
+func isYamlSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func splitYamlKey(text string) (string, string, bool) {
+
+	if text == "" || text[0] == '[' || text[0] == '{' {
+		return "", "", false
+	}
+
+	start := 0
+
+	if text[0] == '"' || text[0] == '\'' {
+
+		end := closingQuote(text, 0)
+
+		if end < 0 {
+			return "", "", false
+		}
+
+		start = end + 1
+	}
+
+	for i := start; i < len(text); i++ {
+
+		if text[i] == ':' && (i == len(text)-1 || text[i+1] == ' ' || text[i+1] == '\t') {
+
+			key := strings.TrimSpace(text[:i])
+
+			if start > 0 && start != len(key) {
+				return "", "", false
+			}
+
+			return key, strings.TrimSpace(text[i+1:]), true
+		}
+	}
+
+	return "", "", false
+}
+
+func yamlKey(raw string, line int) (string, error) {
+
+	if raw != "" && (raw[0] == '"' || raw[0] == '\'') {
+
+		key, err := parseYamlScalar(raw, line)
+
+		if err != nil {
+			return "", err
+		}
+
+		return key.(string), nil
+	}
+
+	if value, err := parseYamlScalar(raw, line); err == nil && value == nil {
+		return "", &DecodeError{line, fmt.Sprintf("null key %q is not supported", raw)}
+	}
+
+	return raw, nil
+}
+
+func closingQuote(text string, open int) int {
+
+	quote := text[open]
+
+	for i := open + 1; i < len(text); i++ {
+
+		if quote == '"' && text[i] == '\\' {
+			i++
+			continue
+		}
+
+		if text[i] == quote {
+
+			if quote == '\'' && i+1 < len(text) && text[i+1] == '\'' {
+				i++
+				continue
+			}
+
+			return i
+		}
+	}
+
+	return -1
+}
+
+func stripYamlComment(text string) string {
+
+	for i := 0; i < len(text); i++ {
+
+		switch text[i] {
+		case '"', '\'':
+			if i == 0 || text[i-1] == ' ' || text[i-1] == '[' || text[i-1] == '{' || text[i-1] == ',' {
+				if end := closingQuote(text, i); end > 0 {
+					i = end
+				}
+			}
+		case '#':
+			if i == 0 || text[i-1] == ' ' || text[i-1] == '\t' {
+				return strings.TrimRight(text[:i], " ")
+			}
+		}
+	}
+
+	return text
+}
+
+func parseYamlScalar(s string, line int) (interface{}, error) {
+
+	if s == "" {
+		return nil, nil
+	}
+
+	switch s[0] {
+	case '"':
+
+		if closingQuote(s, 0) != len(s)-1 {
+			return nil, &DecodeError{line, fmt.Sprintf("unterminated or malformed string %s", s)}
+		}
+
+		return unquoteYaml(s[1:len(s)-1], line)
+
+	case '\'':
+
+		if closingQuote(s, 0) != len(s)-1 {
+			return nil, &DecodeError{line, fmt.Sprintf("unterminated or malformed string %s", s)}
+		}
+
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	}
+
+	switch s {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
+		return math.Inf(1), nil
+	case "-.inf", "-.Inf", "-.INF":
+		return math.Inf(-1), nil
+	case ".nan", ".NaN", ".NAN":
+		return math.NaN(), nil
+	}
+
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0o") {
+
+		if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return float64(i), nil
+		}
+	}
+
+	if yamlNumber.MatchString(s) {
+
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+	}
+
+	return s, nil
+}
+
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f", 'r': "\r",
+	'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\", 'N': "\u0085", '_': "\u00a0", 'L': "\u2028", 'P': "\u2029",
+}
+
+var yamlHexEscapes = map[byte]int{'x': 2, 'u': 4, 'U': 8}
+
+func unquoteYaml(s string, line int) (string, error) {
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		if i+1 >= len(s) {
+			return "", &DecodeError{line, "string ends with an incomplete escape sequence"}
+		}
+
+		i++
+
+		if escaped, found := yamlEscapes[s[i]]; found {
+			b.WriteString(escaped)
+			continue
+		}
+
+		digits, found := yamlHexEscapes[s[i]]
+
+		if !found || i+digits >= len(s) {
+			return "", &DecodeError{line, fmt.Sprintf("invalid escape sequence \\%c", s[i])}
+		}
+
+		r, err := strconv.ParseUint(s[i+1:i+1+digits], 16, 32)
+
+		if err != nil {
+			return "", &DecodeError{line, fmt.Sprintf("invalid escape sequence \\%s", s[i:i+1+digits])}
+		}
+
+		b.WriteRune(rune(r))
+		i += digits
+	}
+
+	return b.String(), nil
+}
+
+type yamlFlowParser struct {
+	s    string
+	pos  int
+	line int
+}
+
+func (fp *yamlFlowParser) parseDocument() (interface{}, error) {
+
+	value, err := fp.parseValue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	fp.skipSpace()
+
+	if fp.pos < len(fp.s) {
+		return nil, fp.error("unexpected content after flow collection")
+	}
+
+	return value, nil
+}
+
+func (fp *yamlFlowParser) error(reason string) error {
+	return &DecodeError{fp.line, reason}
+}
+
+func (fp *yamlFlowParser) skipSpace() {
+
+	for fp.pos < len(fp.s) && (fp.s[fp.pos] == ' ' || fp.s[fp.pos] == '\t') {
+		fp.pos++
+	}
+}
+
+func (fp *yamlFlowParser) parseValue() (interface{}, error) {
+
+	fp.skipSpace()
+
+	if fp.pos >= len(fp.s) {
+		return nil, fp.error("unterminated flow collection")
+	}
+
+	switch fp.s[fp.pos] {
+	case '[':
+		return fp.parseSequence()
+	case '{':
+		return fp.parseMapping()
+	}
+
+	token, err := fp.scalarToken(false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseYamlScalar(token, fp.line)
+}
+
+func (fp *yamlFlowParser) scalarToken(key bool) (string, error) {
+
+	start := fp.pos
+
+	if c := fp.s[fp.pos]; c == '"' || c == '\'' {
+
+		end := closingQuote(fp.s, start)
+
+		if end < 0 {
+			return "", fp.error("unterminated string in flow collection")
+		}
+
+		fp.pos = end + 1
+
+		return fp.s[start:fp.pos], nil
+	}
+
+	for fp.pos < len(fp.s) {
+
+		c := fp.s[fp.pos]
+
+		if c == ',' || c == ']' || c == '}' || (key && c == ':') {
+			break
+		}
+
+		fp.pos++
+	}
+
+	return strings.TrimSpace(fp.s[start:fp.pos]), nil
+}
+
+func (fp *yamlFlowParser) parseSequence() (interface{}, error) {
+
+	fp.pos++
+	s := []interface{}{}
+
+	for {
+
+		fp.skipSpace()
+
+		if fp.pos >= len(fp.s) {
+			return nil, fp.error("unterminated flow sequence")
+		}
+
+		if fp.s[fp.pos] == ']' {
+			fp.pos++
+			return s, nil
+		}
+
+		value, err := fp.parseValue()
+
+		if err != nil {
+			return nil, err
+		}
+
+		s = append(s, value)
+
+		if err := fp.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (fp *yamlFlowParser) parseMapping() (interface{}, error) {
+
+	fp.pos++
+	m := make(map[string]interface{})
+
+	for {
+
+		fp.skipSpace()
+
+		if fp.pos >= len(fp.s) {
+			return nil, fp.error("unterminated flow mapping")
+		}
+
+		if fp.s[fp.pos] == '}' {
+			fp.pos++
+			return m, nil
+		}
+
+		token, err := fp.scalarToken(true)
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := yamlKey(token, fp.line)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fp.skipSpace()
+
+		if fp.pos >= len(fp.s) || fp.s[fp.pos] != ':' {
+			return nil, fp.error(fmt.Sprintf("expected ':' after key %v in flow mapping", key))
+		}
+
+		fp.pos++
+
+		value, err := fp.parseValue()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, duplicate := m[key]; duplicate {
+			return nil, fp.error(fmt.Sprintf("duplicate key %s", key))
+		}
+
+		m[key] = value
+
+		if err := fp.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (fp *yamlFlowParser) separator(end byte) error {
+
+	fp.skipSpace()
+
+	if fp.pos >= len(fp.s) {
+		return fp.error("unterminated flow collection")
+	}
+
+	switch fp.s[fp.pos] {
+	case ',':
+		fp.pos++
+		return nil
+	case end:
+		return nil
+	default:
+		return fp.error(fmt.Sprintf("expected ',' or '%c' in flow collection", end))
+	}
+}
@@ -12,24 +12,59 @@ const accessLogWriterName = ioc.FrameworkPrefix + "AccessLogWriter"
 type HttpServerFacilityBuilder struct {
 }
 
-func (hsfb *HttpServerFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) {
+func (hsfb *HttpServerFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) error {
 
 	httpServer := new(HttpServer)
-	ca.Populate("HttpServer", httpServer)
+	err := ca.Populate("HttpServer", httpServer)
 
-	cn.WrapAndAddProto(httpServerName, httpServer)
+	if err != nil {
+		return err
+	}
+
+	proto := ioc.CreateProtoComponent(httpServer, httpServerName)
+	proto.AddConfigRoot("HttpServer")
+
+	cn.AddProto(proto)
 
 	if !httpServer.AccessLogging {
-		return
+		return nil
 	}
 
 	accessLogWriter := new(AccessLogWriter)
-	ca.Populate("HttpServer.AccessLog", accessLogWriter)
+	err = ca.Populate("HttpServer.AccessLog", accessLogWriter)
+
+	if err != nil {
+		return err
+	}
+
+	proto.AddDependency("AccessLogWriter", accessLogWriterName)
+
+	writerProto := ioc.CreateProtoComponent(accessLogWriter, accessLogWriterName)
+	writerProto.AddConfigRoot("HttpServer.AccessLog")
+
+	cn.AddProto(writerProto)
+
+	return nil
+}
+
+func (hsfb *HttpServerFacilityBuilder) ConfigSchemas() []*config.ConfigSchema {
 
-	httpServer.AccessLogWriter = accessLogWriter
+	s := config.NewConfigSchema("HttpServer")
+	s.Field("Port", config.SchemaInt).Required()
+	s.Field("ContentType", config.SchemaString)
+	s.Field("Encoding", config.SchemaString)
+	s.Field("AccessLogging", config.SchemaBool)
+	s.Field("HealthEndpoints", config.SchemaBool)
+	s.Field("LivenessPath", config.SchemaString)
+	s.Field("ReadinessPath", config.SchemaString)
 
-	cn.WrapAndAddProto(accessLogWriterName, accessLogWriter)
+	al := s.Object("AccessLog")
+	al.Field("LogPath", config.SchemaString)
+	al.Field("LogLineFormat", config.SchemaString)
+	al.Field("LogLinePreset", config.SchemaString)
+	al.Field("UtcTimes", config.SchemaBool)
 
+	return []*config.ConfigSchema{s}
 }
 
 func (hsfb *HttpServerFacilityBuilder) FacilityName() string {
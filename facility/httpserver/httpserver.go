@@ -1,11 +1,14 @@
 package httpserver
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/wolferton/quilt/ioc"
 	"github.com/wolferton/quilt/logging"
+	"net"
 	"net/http"
 	"regexp"
+	"sync"
 	"time"
 )
 
@@ -16,20 +19,25 @@ type RegisteredProvider struct {
 
 type HttpServer struct {
 	registeredProvidersByMethod map[string][]*RegisteredProvider
+	providersMutex              sync.RWMutex
 	componentContainer          *ioc.ComponentContainer
+	server                      *http.Server
 	FrameworkLogger             logging.Logger
 	AccessLogWriter             *AccessLogWriter
 	AccessLogging               bool
 	Port                        int
 	ContentType                 string
 	Encoding                    string
+	HealthEndpoints             bool
+	LivenessPath                string
+	ReadinessPath               string
 }
 
 func (hs *HttpServer) Container(container *ioc.ComponentContainer) {
 	hs.componentContainer = container
 }
 
-func (hs *HttpServer) registerProvider(endPointProvider HttpEndpointProvider) {
+func (hs *HttpServer) registerProvider(endPointProvider HttpEndpointProvider, registeredProvidersByMethod map[string][]*RegisteredProvider) {
 
 	for _, method := range endPointProvider.SupportedHttpMethods() {
 
@@ -44,14 +52,14 @@ func (hs *HttpServer) registerProvider(endPointProvider HttpEndpointProvider) {
 
 		rp := RegisteredProvider{endPointProvider, compiledRegex}
 
-		providersForMethod := hs.registeredProvidersByMethod[method]
+		providersForMethod := registeredProvidersByMethod[method]
 
 		if providersForMethod == nil {
 			providersForMethod = make([]*RegisteredProvider, 1)
 			providersForMethod[0] = &rp
-			hs.registeredProvidersByMethod[method] = providersForMethod
+			registeredProvidersByMethod[method] = providersForMethod
 		} else {
-			hs.registeredProvidersByMethod[method] = append(providersForMethod, &rp)
+			registeredProvidersByMethod[method] = append(providersForMethod, &rp)
 		}
 	}
 
@@ -59,7 +67,23 @@ func (hs *HttpServer) registerProvider(endPointProvider HttpEndpointProvider) {
 
 func (hs *HttpServer) StartComponent() error {
 
-	hs.registeredProvidersByMethod = make(map[string][]*RegisteredProvider)
+	hs.registerProviders()
+
+	return nil
+}
+
+func (hs *HttpServer) ComponentsReloaded(names []string) error {
+
+	hs.FrameworkLogger.LogDebugf("Re-registering HttpEndpointProviders after reload of %v", names)
+
+	hs.registerProviders()
+
+	return nil
+}
+
+func (hs *HttpServer) registerProviders() {
+
+	registeredProvidersByMethod := make(map[string][]*RegisteredProvider)
 
 	for name, component := range hs.componentContainer.AllComponents() {
 		provider, found := component.Instance.(HttpEndpointProvider)
@@ -67,26 +91,57 @@ func (hs *HttpServer) StartComponent() error {
 		if found {
 			hs.FrameworkLogger.LogDebugf("Found HttpEndpointProvider %s", name)
 
-			hs.registerProvider(provider)
+			hs.registerProvider(provider, registeredProvidersByMethod)
 
 		}
 	}
 
-	return nil
+	hs.providersMutex.Lock()
+	hs.registeredProvidersByMethod = registeredProvidersByMethod
+	hs.providersMutex.Unlock()
 }
 
 func (hs *HttpServer) AllowAccess() error {
-	http.Handle("/", http.HandlerFunc(hs.handleAll))
 
 	listenAddress := fmt.Sprintf(":%d", hs.Port)
+	listener, err := net.Listen("tcp", listenAddress)
+
+	if err != nil {
+		return err
+	}
+
+	hs.server = &http.Server{Handler: http.HandlerFunc(hs.handleAll)}
 
-	go http.ListenAndServe(listenAddress, nil)
+	go hs.serve(hs.server, listener)
 
 	hs.FrameworkLogger.LogInfof("HTTP server started listening on %d", hs.Port)
 
 	return nil
 }
 
+func (hs *HttpServer) serve(server *http.Server, listener net.Listener) {
+
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		hs.FrameworkLogger.LogErrorf("HTTP server stopped unexpectedly: %s", err)
+	}
+}
+
+func (hs *HttpServer) PrepareToStop() {
+}
+
+func (hs *HttpServer) ReadyToStop() (bool, error) {
+	return true, nil
+}
+
+func (hs *HttpServer) Stop() error {
+
+	if hs.server == nil {
+		return nil
+	}
+
+	return hs.server.Close()
+}
+
 func (h *HttpServer) handleAll(responseWriter http.ResponseWriter, request *http.Request) {
 
 	received := time.Now()
@@ -95,7 +150,9 @@ func (h *HttpServer) handleAll(responseWriter http.ResponseWriter, request *http
 	contentType := fmt.Sprintf("%s; charset=%s", h.ContentType, h.Encoding)
 	responseWriter.Header().Set("Content-Type", contentType)
 
+	h.providersMutex.RLock()
 	providersByMethod := h.registeredProvidersByMethod[request.Method]
+	h.providersMutex.RUnlock()
 
 	path := request.URL.Path
 
@@ -104,6 +161,12 @@ func (h *HttpServer) handleAll(responseWriter http.ResponseWriter, request *http
 	wrw := new(wrappedResponseWriter)
 	wrw.rw = responseWriter
 
+	if h.HealthEndpoints && (path == h.LivenessPath || path == h.ReadinessPath) {
+		matched = true
+		providersByMethod = nil
+		h.handleHealth(wrw, path == h.ReadinessPath)
+	}
+
 	for _, handlerPattern := range providersByMethod {
 
 		pattern := handlerPattern.Pattern
@@ -128,6 +191,27 @@ func (h *HttpServer) handleAll(responseWriter http.ResponseWriter, request *http
 
 }
 
+func (h *HttpServer) handleHealth(res *wrappedResponseWriter, readiness bool) {
+
+	report := h.componentContainer.Health()
+
+	healthy := report.Live
+
+	if readiness {
+		healthy = report.Ready
+	}
+
+	if healthy {
+		res.WriteHeader(http.StatusOK)
+	} else {
+		res.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	if err := json.NewEncoder(res).Encode(report); err != nil {
+		h.FrameworkLogger.LogErrorf("Unable to write health report: %s", err.Error())
+	}
+}
+
 func (h *HttpServer) handleNotFound(req *http.Request, res *wrappedResponseWriter) {
 
 	http.NotFound(res, req)
@@ -1,7 +1,7 @@
 package jsonmerger
 
 import (
-	"encoding/json"
+	"fmt"
 	"github.com/wolferton/quilt/config"
 	"github.com/wolferton/quilt/logging"
 	"io/ioutil"
@@ -13,7 +13,7 @@ type JsonMerger struct {
 	Logger logging.Logger
 }
 
-func (jm *JsonMerger) LoadAndMergeConfig(files []string) map[string]interface{} {
+func (jm *JsonMerger) LoadAndMergeConfig(files []string) (map[string]interface{}, error) {
 
 	var mergedConfig map[string]interface{}
 
@@ -21,14 +21,17 @@ func (jm *JsonMerger) LoadAndMergeConfig(files []string) map[string]interface{}
 
 		jm.Logger.LogTracef("Reading %s", fileName)
 
-		jsonData, err := ioutil.ReadFile(fileName)
-		jm.check(err)
+		data, err := ioutil.ReadFile(fileName)
 
-		var loadedConfig interface{}
-		err = json.Unmarshal(jsonData, &loadedConfig)
-		jm.check(err)
+		if err != nil {
+			return nil, err
+		}
+
+		additionalConfig, err := config.DecoderFor(fileName).Decode(data)
 
-		additionalConfig := loadedConfig.(map[string]interface{})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to decode %s: %s", fileName, err)
+		}
 
 		if index == 0 {
 			mergedConfig = additionalConfig
@@ -38,7 +41,7 @@ func (jm *JsonMerger) LoadAndMergeConfig(files []string) map[string]interface{}
 
 	}
 
-	return mergedConfig
+	return mergedConfig, nil
 }
 
 func (jm *JsonMerger) merge(base, additional map[string]interface{}) map[string]interface{} {
@@ -65,9 +68,3 @@ func (jm *JsonMerger) merge(base, additional map[string]interface{}) map[string]
 
 	return base
 }
-
-func (jm *JsonMerger) check(e error) {
-	if e != nil {
-		panic(e)
-	}
-}
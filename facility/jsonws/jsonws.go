@@ -20,7 +20,7 @@ const wsFrameworkErrorGenerator = ioc.FrameworkPrefix + "FrameworkErrorGenerator
 type JsonWsFacilityBuilder struct {
 }
 
-func (fb *JsonWsFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) {
+func (fb *JsonWsFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) error {
 
 	responseWriter := new(json.DefaultJsonResponseWriter)
 	cn.WrapAndAddProto(jsonResponseWriterComponentName, responseWriter)
@@ -38,12 +38,35 @@ func (fb *JsonWsFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerMan
 	cn.WrapAndAddProto(jsonUnmarshallerComponentName, jsonUnmarshaller)
 
 	frameworkErrors := new(serviceerror.FrameworkErrorGenerator)
-	ca.Populate("FrameworkServiceErrors", frameworkErrors)
+	err := ca.Populate("FrameworkServiceErrors", frameworkErrors)
+
+	if err != nil {
+		return err
+	}
+
 	cn.WrapAndAddProto(wsFrameworkErrorGenerator, frameworkErrors)
 
 	decoratorLogger := lm.CreateLogger(jsonHandlerDecoratorComponentName)
 	decorator := JsonWsHandlerDecorator{decoratorLogger, responseWriter, abnormalResponseWriter, statusDeterminer, jsonUnmarshaller, queryBinder, frameworkErrors}
 	cn.WrapAndAddProto(jsonHandlerDecoratorComponentName, &decorator)
+
+	return nil
+}
+
+func (fb *JsonWsFacilityBuilder) ConfigSchemas() []*config.ConfigSchema {
+
+	s := config.NewConfigSchema("JsonWs")
+
+	rw := s.Object("ResponseWrapping")
+	rw.Field("Wrap", config.SchemaBool)
+	rw.Field("IncludeEmptySections", config.SchemaBool)
+	rw.Field("ContentLabel", config.SchemaString)
+	rw.Field("ErrorsLabel", config.SchemaString)
+
+	fe := config.NewConfigSchema("FrameworkServiceErrors")
+	fe.Field("Messages", config.SchemaObject)
+
+	return []*config.ConfigSchema{s, fe}
 }
 
 func (fb *JsonWsFacilityBuilder) FacilityName() string {
@@ -13,11 +13,20 @@ const applicationLoggingManagerName = ioc.FrameworkPrefix + "ApplicationLoggingM
 type ApplicationLoggingFacilityBuilder struct {
 }
 
-func (alfb *ApplicationLoggingFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) {
-	defaultLogLevelLabel := ca.StringVal("ApplicationLogger.DefaultLogLevel")
+func (alfb *ApplicationLoggingFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) error {
+	defaultLogLevelLabel, err := ca.String("ApplicationLogger.DefaultLogLevel")
+
+	if err != nil {
+		return err
+	}
+
 	defaultLogLevel := logging.LogLevelFromLabel(defaultLogLevelLabel)
 
-	initialLogLevelsByComponent := ca.ObjectVal("ApplicationLogger.ComponentLogLevels")
+	initialLogLevelsByComponent, err := ca.ObjectOrDefault("ApplicationLogger.ComponentLogLevels", nil)
+
+	if err != nil {
+		return err
+	}
 
 	applicationLoggingManager := logging.CreateComponentLoggerManager(defaultLogLevel, initialLogLevelsByComponent)
 	cn.WrapAndAddProto(applicationLoggingManagerName, applicationLoggingManager)
@@ -27,6 +36,21 @@ func (alfb *ApplicationLoggingFacilityBuilder) BuildAndRegister(lm *logging.Comp
 	applicationLoggingDecorator.FrameworkLogger = lm.CreateLogger(applicationLoggingDecoratorName)
 
 	cn.WrapAndAddProto(applicationLoggingDecoratorName, applicationLoggingDecorator)
+
+	return nil
+}
+
+func (alfb *ApplicationLoggingFacilityBuilder) ConfigSchemas() []*config.ConfigSchema {
+	return []*config.ConfigSchema{LoggerSchema("ApplicationLogger")}
+}
+
+func LoggerSchema(path string) *config.ConfigSchema {
+
+	s := config.NewConfigSchema(path)
+	s.Field("DefaultLogLevel", config.SchemaString).Required().Allowed(logging.TraceLabel, logging.DebugLabel, logging.InfoLabel, logging.WarnLabel, logging.ErrorLabel, logging.FatalLabel)
+	s.Field("ComponentLogLevels", config.SchemaObject)
+
+	return s
 }
 
 func (alfb *ApplicationLoggingFacilityBuilder) FacilityName() string {
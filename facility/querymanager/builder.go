@@ -12,13 +12,35 @@ const QueryManagerFacilityName = "QueryManager"
 type QueryManagerFacilityBuilder struct {
 }
 
-func (qmfb *QueryManagerFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) {
+func (qmfb *QueryManagerFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) error {
 
 	queryManager := new(QueryManager)
-	ca.Populate("QueryManager", queryManager)
+	err := ca.Populate("QueryManager", queryManager)
 
-	cn.WrapAndAddProto(QueryManagerComponentName, queryManager)
+	if err != nil {
+		return err
+	}
 
+	proto := ioc.CreateProtoComponent(queryManager, QueryManagerComponentName)
+	proto.AddConfigRoot("QueryManager")
+
+	cn.AddProto(proto)
+
+	return nil
+}
+
+func (qmfb *QueryManagerFacilityBuilder) ConfigSchemas() []*config.ConfigSchema {
+
+	s := config.NewConfigSchema("QueryManager")
+	s.Field("TemplateLocation", config.SchemaString).Required()
+	s.Field("QueryIdPrefix", config.SchemaString)
+	s.Field("TrimIdWhiteSpace", config.SchemaBool)
+	s.Field("VarMatchRegEx", config.SchemaString)
+	s.Field("WrapStrings", config.SchemaBool)
+	s.Field("StringWrapWith", config.SchemaString)
+	s.Field("NewLine", config.SchemaString)
+
+	return []*config.ConfigSchema{s}
 }
 
 func (qmfb *QueryManagerFacilityBuilder) FacilityName() string {
@@ -12,18 +12,32 @@ const rdbmsClientManagerName = ioc.FrameworkPrefix + "RdbmsClientManager"
 type RdbmsAccessFacilityBuilder struct {
 }
 
-func (rafb *RdbmsAccessFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) {
+func (rafb *RdbmsAccessFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) error {
 
 	manager := new(DefaultRdbmsClientManager)
-	ca.Populate("RdbmsAccess", manager)
+	err := ca.Populate("RdbmsAccess", manager)
+
+	if err != nil {
+		return err
+	}
 
 	proto := ioc.CreateProtoComponent(manager, rdbmsClientManagerName)
+	proto.AddConfigRoot("RdbmsAccess")
 
 	proto.AddDependency("Provider", manager.DatabaseProviderComponentName)
 	proto.AddDependency("QueryManager", querymanager.QueryManagerComponentName)
 
 	cn.AddProto(proto)
 
+	return nil
+}
+
+func (rafb *RdbmsAccessFacilityBuilder) ConfigSchemas() []*config.ConfigSchema {
+
+	s := config.NewConfigSchema("RdbmsAccess")
+	s.Field("DatabaseProviderComponentName", config.SchemaString).Required()
+
+	return []*config.ConfigSchema{s}
 }
 
 func (rafb *RdbmsAccessFacilityBuilder) FacilityName() string {
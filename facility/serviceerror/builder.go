@@ -9,17 +9,51 @@ import (
 type ServiceErrorManagerFacilityBuilder struct {
 }
 
-func (fb *ServiceErrorManagerFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) {
+func (fb *ServiceErrorManagerFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, cn *ioc.ComponentContainer) error {
 
-	manager := new(ServiceErrorManager)
-	manager.PanicOnMissing = ca.BoolValue("ServiceErrorManager.PanicOnMissing")
-	cn.WrapAndAddProto(serviceErrorManagerComponentName, manager)
+	definitions, err := ca.String("ServiceErrorManager.ErrorDefinitions")
+
+	if err != nil {
+		return err
+	}
+
+	build := func(ca *config.ConfigAccessor) (interface{}, error) {
+		return buildManager(lm, ca, definitions)
+	}
+
+	manager, err := build(ca)
+
+	if err != nil {
+		return err
+	}
+
+	proto := ioc.CreateProtoComponent(manager, serviceErrorManagerComponentName)
+	proto.AddConfigRoot("ServiceErrorManager")
+	proto.AddConfigRoot(definitions)
+	proto.Builder = build
+
+	cn.AddProto(proto)
+
+	decorator := ioc.CreateProtoComponent(new(ServiceErrorConsumerDecorator), serviceErrorDecoratorComponentName)
+	decorator.AddDependency("ErrorSource", serviceErrorManagerComponentName)
+
+	cn.AddProto(decorator)
 
-	decorator := new(ServiceErrorConsumerDecorator)
-	decorator.ErrorSource = manager
-	cn.WrapAndAddProto(serviceErrorDecoratorComponentName, decorator)
+	return nil
+}
+
+func buildManager(lm *logging.ComponentLoggerManager, ca *config.ConfigAccessor, definitions string) (*ServiceErrorManager, error) {
+
+	panicOnMissing, err := ca.BoolOrDefault("ServiceErrorManager.PanicOnMissing", false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	manager := new(ServiceErrorManager)
+	manager.PanicOnMissing = panicOnMissing
+	manager.FrameworkLogger = lm.CreateLogger(serviceErrorManagerComponentName)
 
-	definitions := ca.StringVal("ServiceErrorManager.ErrorDefinitions")
 	errors := ca.Array(definitions)
 
 	if errors == nil {
@@ -27,6 +61,17 @@ func (fb *ServiceErrorManagerFacilityBuilder) BuildAndRegister(lm *logging.Compo
 	} else {
 		manager.LoadErrors(errors)
 	}
+
+	return manager, nil
+}
+
+func (fb *ServiceErrorManagerFacilityBuilder) ConfigSchemas() []*config.ConfigSchema {
+
+	s := config.NewConfigSchema("ServiceErrorManager")
+	s.Field("PanicOnMissing", config.SchemaBool)
+	s.Field("ErrorDefinitions", config.SchemaString).Required()
+
+	return []*config.ConfigSchema{s}
 }
 
 func (fb *ServiceErrorManagerFacilityBuilder) FacilityName() string {
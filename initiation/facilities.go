@@ -25,6 +25,8 @@ type FacilitiesInitialisor struct {
 	Logger                  logging.Logger
 	container               *ioc.ComponentContainer
 	facilities              []facility.FacilityBuilder
+	schemas                 []*config.ConfigSchema
+	configSchemas           []*config.ConfigSchema
 	facilityStatus          map[string]interface{}
 }
 
@@ -49,6 +51,48 @@ func (fi *FacilitiesInitialisor) AddFacility(f facility.FacilityBuilder) {
 	fi.facilities = append(fi.facilities, f)
 }
 
+func (fi *FacilitiesInitialisor) AddSchemas(schemas ...*config.ConfigSchema) {
+	fi.schemas = append(fi.schemas, schemas...)
+}
+
+func (fi *FacilitiesInitialisor) buildConfigSchemas() {
+
+	facilities := config.NewConfigSchema("Facilities")
+	facilities.Field("FrameworkLogging", config.SchemaBool)
+	facilities.Field("ApplicationLogging", config.SchemaBool)
+
+	schemas := []*config.ConfigSchema{facilities, ioc.ContainerSettingsSchema(), logger.LoggerSchema("FrameworkLogger")}
+
+	for _, fb := range fi.facilities {
+
+		name := fb.FacilityName()
+		facilities.Field(name, config.SchemaBool)
+
+		enabled, found := fi.facilityStatus[name].(bool)
+
+		if sp, isProvider := fb.(config.SchemaProvider); isProvider && found && enabled {
+			schemas = append(schemas, sp.ConfigSchemas()...)
+		}
+	}
+
+	fi.configSchemas = append(schemas, fi.schemas...)
+}
+
+func (fi *FacilitiesInitialisor) ValidateConfig(ca *config.ConfigAccessor) error {
+
+	problems := []error{}
+
+	for _, schema := range fi.configSchemas {
+		problems = append(problems, schema.Validate(ca)...)
+	}
+
+	if len(problems) > 0 {
+		return &config.SchemaErrors{Problems: problems}
+	}
+
+	return nil
+}
+
 func (fi *FacilitiesInitialisor) buildEnabledFacilities() error {
 
 	for _, fb := range fi.facilities {
@@ -73,7 +117,11 @@ func (fi *FacilitiesInitialisor) buildEnabledFacilities() error {
 
 			}
 
-			fb.BuildAndRegister(fi.FrameworkLoggingManager, fi.ConfigAccessor, fi.container)
+			err := fb.BuildAndRegister(fi.FrameworkLoggingManager, fi.ConfigAccessor, fi.container)
+
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -84,11 +132,21 @@ func (fi *FacilitiesInitialisor) buildEnabledFacilities() error {
 func (fi *FacilitiesInitialisor) Initialise(ca *config.ConfigAccessor) error {
 	fi.ConfigAccessor = ca
 
-	fc := ca.ObjectVal("Facilities")
+	fc, err := ca.Object("Facilities")
+
+	if err != nil {
+		return err
+	}
+
 	fi.facilityStatus = fc
-	fi.updateFrameworkLogLevel()
 
-	if fc["ApplicationLogging"].(bool) {
+	applicationLogging, err := ca.BoolOrDefault("Facilities.ApplicationLogging", false)
+
+	if err != nil {
+		return err
+	}
+
+	if applicationLogging {
 		fi.AddFacility(new(logger.ApplicationLoggingFacilityBuilder))
 	}
 
@@ -98,19 +156,36 @@ func (fi *FacilitiesInitialisor) Initialise(ca *config.ConfigAccessor) error {
 	fi.AddFacility(new(serviceerror.ServiceErrorManagerFacilityBuilder))
 	fi.AddFacility(new(rdbms.RdbmsAccessFacilityBuilder))
 
-	err := fi.buildEnabledFacilities()
+	fi.buildConfigSchemas()
 
-	return err
+	if err := fi.ValidateConfig(ca); err != nil {
+		return err
+	}
+
+	if err := fi.updateFrameworkLogLevel(); err != nil {
+		return err
+	}
+
+	return fi.buildEnabledFacilities()
 }
 
-func (fi *FacilitiesInitialisor) updateFrameworkLogLevel() {
+func (fi *FacilitiesInitialisor) updateFrameworkLogLevel() error {
 
 	flm := fi.FrameworkLoggingManager
 
-	defaultLogLevelLabel := fi.ConfigAccessor.StringVal("FrameworkLogger.DefaultLogLevel")
+	defaultLogLevelLabel, err := fi.ConfigAccessor.String("FrameworkLogger.DefaultLogLevel")
+
+	if err != nil {
+		return err
+	}
+
 	defaultLogLevel := logging.LogLevelFromLabel(defaultLogLevelLabel)
 
-	initialLogLevelsByComponent := fi.ConfigAccessor.ObjectVal("FrameworkLogger.ComponentLogLevels")
+	initialLogLevelsByComponent, err := fi.ConfigAccessor.ObjectOrDefault("FrameworkLogger.ComponentLogLevels", nil)
+
+	if err != nil {
+		return err
+	}
 
 	flm.InitalComponentLogLevels = initialLogLevelsByComponent
 	flm.UpdateGlobalThreshold(defaultLogLevel)
@@ -122,4 +197,5 @@ func (fi *FacilitiesInitialisor) updateFrameworkLogLevel() {
 
 	fi.container.WrapAndAddProto(frameworkLoggerDecoratorName, fld)
 
+	return nil
 }
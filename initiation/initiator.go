@@ -1,6 +1,7 @@
 package initiation
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/wolferton/quilt/config"
@@ -21,7 +22,11 @@ const configAccessorComponentName string = ioc.FrameworkPrefix + "ConfigAccessor
 const facilityInitialisorComponentName string = ioc.FrameworkPrefix + "FacilityInitialisor"
 
 type Initiator struct {
-	logger logging.Logger
+	Properties              map[string]string
+	logger                  logging.Logger
+	configPath              string
+	frameworkLoggingManager *logging.ComponentLoggerManager
+	facilitiesInitialisor   *FacilitiesInitialisor
 }
 
 func (i *Initiator) Start(customComponents []*ioc.ProtoComponent) {
@@ -33,88 +38,190 @@ func (i *Initiator) Start(customComponents []*ioc.ProtoComponent) {
 		os.Exit(-1)
 	}
 
-	var params map[string]string
-	/*protoComponents := make(map[string]*ioc.ProtoComponent)
+	params, err := i.parseArgs()
+
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	container, err := i.Prepare(params, customComponents)
+	i.shutdownIfError(err, container)
+
+	err = i.Launch(container)
+	i.shutdownIfError(err, container)
+
+	elapsed := time.Since(start)
+	i.logger.LogInfof("Ready (startup time %s)", elapsed)
+
+	if container.Settings().HotReload {
+		i.reloadOnHangup(container)
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	signal.Notify(c, syscall.SIGTERM)
 
-	for _, c := range customComponents {
-		protoComponents[c.Component.Name] = c
-	}*/
+	go func() {
+		<-c
+		i.shutdown(container)
+		os.Exit(1)
+	}()
 
-	params = i.parseArgs()
+	for {
+		time.Sleep(100000000000)
+	}
+}
+
+func (i *Initiator) Prepare(params map[string]string, customComponents []*ioc.ProtoComponent) (*ioc.ComponentContainer, error) {
 
 	bootstrapLogLevel := logging.LogLevelFromLabel(params["logLevel"])
 	frameworkLoggingManager, logManageProto := BootstrapFrameworkLogging(bootstrapLogLevel)
 	i.logger = frameworkLoggingManager.CreateLogger(initiatorComponentName)
+	i.frameworkLoggingManager = frameworkLoggingManager
+	i.configPath = params["config"]
 
 	i.logger.LogInfof("Starting components")
 
-	configAccessor := i.loadConfigIntoAccessor(params["config"], frameworkLoggingManager)
+	configAccessor, err := i.loadConfigIntoAccessor(params["config"], frameworkLoggingManager)
+
+	if err != nil {
+		return nil, err
+	}
+
 	container := ioc.NewContainer(frameworkLoggingManager, configAccessor)
 
+	if params["profiles"] != "" {
+		container.ActivateProfiles(strings.Split(params["profiles"], ","))
+	}
+
 	container.AddProto(logManageProto)
 	container.AddProtos(customComponents)
 
 	facilitiesInitialisor := NewFacilitiesInitialisor(container, frameworkLoggingManager)
 	facilitiesInitialisor.Logger = frameworkLoggingManager.CreateLogger(facilityInitialisorComponentName)
 
-	err := facilitiesInitialisor.Initialise(configAccessor)
-	i.shutdownIfError(err, container)
+	for _, proto := range customComponents {
 
-	err = container.Populate()
-	i.shutdownIfError(err, container)
+		if sp, found := proto.Component.Instance.(config.SchemaProvider); found {
+			facilitiesInitialisor.AddSchemas(sp.ConfigSchemas()...)
+		}
+	}
+
+	i.facilitiesInitialisor = facilitiesInitialisor
+	err = facilitiesInitialisor.Initialise(configAccessor)
+
+	return container, err
+}
+
+func (i *Initiator) Launch(container *ioc.ComponentContainer) error {
+
+	err := container.Populate()
+
+	if err != nil {
+		return err
+	}
 
 	runtime.GC()
 
-	err = container.StartComponents()
-	i.shutdownIfError(err, container)
+	return container.StartComponents()
+}
 
-	elapsed := time.Since(start)
-	i.logger.LogInfof("Ready (startup time %s)", elapsed)
+func (i *Initiator) Reload(container *ioc.ComponentContainer) (*ioc.ReloadReport, error) {
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	signal.Notify(c, syscall.SIGTERM)
+	i.logger.LogInfof("Reloading configuration")
+
+	configAccessor, err := i.loadConfigIntoAccessor(i.configPath, i.frameworkLoggingManager)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.facilitiesInitialisor.ValidateConfig(configAccessor); err != nil {
+		return nil, err
+	}
+
+	report, err := container.Reload(configAccessor)
+
+	if err != nil {
+		return report, err
+	}
+
+	i.logger.LogInfof("Reload complete. Rebuilt %v (%s)", report.Rebuilt, report.Elapsed)
+
+	return report, nil
+}
+
+func (i *Initiator) reloadOnHangup(container *ioc.ComponentContainer) {
+
+	h := make(chan os.Signal, 1)
+	signal.Notify(h, syscall.SIGHUP)
 
 	go func() {
-		<-c
-		i.shutdown(container)
-		os.Exit(1)
+		for range h {
+			if _, err := i.Reload(container); err != nil {
+				i.logger.LogErrorf("Reload failed: %s", err.Error())
+			}
+		}
 	}()
+}
 
-	for {
-		time.Sleep(100000000000)
-	}
+func (i *Initiator) Shutdown(container *ioc.ComponentContainer) *ioc.ShutdownReport {
+	return i.shutdown(container)
 }
 
 func (i *Initiator) shutdownIfError(err error, c *ioc.ComponentContainer) {
 
 	if err != nil {
 		i.logger.LogFatalf(err.Error())
-		i.shutdown(c)
+		i.logger.LogInfof("Aborting startup")
+
+		if c != nil {
+			i.shutdown(c)
+		}
+
 		os.Exit(-1)
 	}
 
 }
 
-func (i *Initiator) shutdown(container *ioc.ComponentContainer) {
+func (i *Initiator) shutdown(container *ioc.ComponentContainer) *ioc.ShutdownReport {
 	i.logger.LogInfof("Shutting down")
 
-	container.ShutdownComponents()
+	report := container.ShutdownComponents(context.Background())
 
-}
+	if !report.Clean() {
+		i.logger.LogWarnf("Shutdown was not clean. Not ready to stop: %v", report.NotReady)
 
-func (i *Initiator) loadConfigIntoAccessor(configPath string, frameworkLoggingManager *logging.ComponentLoggerManager) *config.ConfigAccessor {
-	configFiles := i.builtInConfigPaths()
+		for _, cst := range report.Errored() {
+			i.logger.LogWarnf("%s: %s", cst.Name, cst.Err)
+		}
+	}
 
-	expandedPaths, err := config.ExpandToFiles(i.splitConfigPaths(configPath))
-	fl := frameworkLoggingManager.CreateLogger(configAccessorComponentName)
+	i.logger.LogInfof("Shutdown complete (%s)", report.Elapsed)
+
+	return report
+}
+
+func (i *Initiator) loadConfigIntoAccessor(configPath string, frameworkLoggingManager *logging.ComponentLoggerManager) (*config.ConfigAccessor, error) {
+	configFiles, err := i.builtInConfigPaths()
 
 	if err != nil {
-		i.logger.LogFatalf("Unable to load specified config files: %s", err.Error())
-		return nil
+		return nil, err
 	}
 
-	configFiles = append(configFiles, expandedPaths...)
+	fl := frameworkLoggingManager.CreateLogger(configAccessorComponentName)
+
+	if configPath != "" {
+
+		expandedPaths, err := config.ExpandToFiles(i.splitConfigPaths(configPath))
+
+		if err != nil {
+			return nil, fmt.Errorf("Unable to load specified config files: %s", err.Error())
+		}
+
+		configFiles = append(configFiles, expandedPaths...)
+	}
 
 	if i.logger.IsLevelEnabled(logging.Debug) {
 
@@ -128,31 +235,80 @@ func (i *Initiator) loadConfigIntoAccessor(configPath string, frameworkLoggingMa
 	jsonMerger := new(jsonmerger.JsonMerger)
 	jsonMerger.Logger = frameworkLoggingManager.CreateLogger(jsonMergerComponentName)
 
-	mergedJson := jsonMerger.LoadAndMergeConfig(configFiles)
+	mergedJson, err := jsonMerger.LoadAndMergeConfig(configFiles)
 
-	return &config.ConfigAccessor{mergedJson, fl}
+	if err != nil {
+		return nil, err
+	}
+
+	return config.NewLayeredConfigAccessor(mergedJson, fl, config.EnvironmentOverrides(os.Environ()), config.PropertyOverrides(i.Properties))
 }
 
-func (i *Initiator) parseArgs() map[string]string {
+func (i *Initiator) parseArgs() (map[string]string, error) {
 	configFilePtr := flag.String("c", "resource/config", "Path to container configuration files")
 	startupLogLevel := flag.String("l", "INFO", "Logging threshold for messages from components during bootstrap")
-	flag.Parse()
+	profiles := flag.String("p", "", "A comma separated list of profiles to activate")
+	flag.String("D", "", "Override a configuration value, e.g. -DHttpServer.Port=9000 (precedence: -D, then QUILT_ environment variables, then JSON files)")
+
+	properties, remaining, err := i.extractProperties(os.Args[1:])
+
+	if err != nil {
+		return nil, err
+	}
+
+	i.Properties = properties
+
+	flag.CommandLine.Parse(remaining)
 
 	var params map[string]string
 	params = make(map[string]string)
 
 	params["config"] = *configFilePtr
 	params["logLevel"] = *startupLogLevel
+	params["profiles"] = *profiles
+
+	return params, nil
 
-	return params
+}
+
+func (i *Initiator) extractProperties(args []string) (map[string]string, []string, error) {
+
+	properties := make(map[string]string)
+	remaining := []string{}
+
+	for j := 0; j < len(args); j++ {
+
+		arg := args[j]
+
+		if !strings.HasPrefix(arg, "-D") {
+			remaining = append(remaining, arg)
+			continue
+		}
+
+		property := strings.TrimPrefix(arg, "-D")
+
+		if property == "" && j+1 < len(args) {
+			j++
+			property = args[j]
+		}
+
+		kv := strings.SplitN(property, "=", 2)
+
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, nil, fmt.Errorf("Malformed property %s (expected -Dpath=value)", property)
+		}
+
+		properties[kv[0]] = kv[1]
+	}
 
+	return properties, remaining, nil
 }
 
 func (i *Initiator) splitConfigPaths(pathArgument string) []string {
 	return strings.Split(pathArgument, ",")
 }
 
-func (i *Initiator) builtInConfigPaths() []string {
+func (i *Initiator) builtInConfigPaths() ([]string, error) {
 
 	const builtInConfigPath = "/resource/facility-config"
 
@@ -161,9 +317,9 @@ func (i *Initiator) builtInConfigPaths() []string {
 	files, err := config.FindConfigFilesInDir(configFolder)
 
 	if err != nil {
-		i.logger.LogFatalf("Unable to load config from folder %s: %s", configFolder, err.Error())
+		return nil, fmt.Errorf("Unable to load config from folder %s: %s", configFolder, err.Error())
 	}
 
-	return files
+	return files, nil
 
 }
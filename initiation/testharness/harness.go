@@ -0,0 +1,133 @@
+package testharness
+
+import (
+	"errors"
+	"github.com/wolferton/quilt/config"
+	"github.com/wolferton/quilt/initiation"
+	"github.com/wolferton/quilt/ioc"
+	"strings"
+)
+
+type Harness struct {
+	ConfigPaths      []string
+	LogLevel         string
+	Profiles         []string
+	Properties       map[string]string
+	customComponents []*ioc.ProtoComponent
+	overrides        []*ioc.ProtoComponent
+	initiator        *initiation.Initiator
+	container        *ioc.ComponentContainer
+}
+
+func NewHarness(customComponents []*ioc.ProtoComponent, configPaths ...string) *Harness {
+
+	h := new(Harness)
+	h.customComponents = customComponents
+	h.ConfigPaths = configPaths
+	h.LogLevel = "ERROR"
+
+	return h
+}
+
+func (h *Harness) Override(name string, instance interface{}) {
+	h.OverrideProto(ioc.CreateProtoComponent(instance, name))
+}
+
+func (h *Harness) OverrideProto(proto *ioc.ProtoComponent) {
+	h.overrides = append(h.overrides, proto)
+}
+
+func (h *Harness) Start() error {
+
+	if config.QuiltHome() == "" {
+		return errors.New("QUILT_HOME environment variable not set")
+	}
+
+	if h.container != nil {
+		return errors.New("Harness already started - call Shutdown before starting again")
+	}
+
+	params := make(map[string]string)
+	params["config"] = strings.Join(h.ConfigPaths, ",")
+	params["logLevel"] = h.LogLevel
+	params["profiles"] = strings.Join(h.Profiles, ",")
+
+	h.initiator = new(initiation.Initiator)
+	h.initiator.Properties = h.properties()
+
+	container, err := h.initiator.Prepare(params, h.customComponents)
+
+	if err != nil {
+		return err
+	}
+
+	for _, proto := range h.overrides {
+
+		if err := container.ReplaceProto(proto); err != nil {
+			return err
+		}
+	}
+
+	if err := h.initiator.Launch(container); err != nil {
+		h.initiator.Shutdown(container)
+		return err
+	}
+
+	h.container = container
+
+	return nil
+}
+
+func (h *Harness) properties() map[string]string {
+
+	properties := map[string]string{
+		"FrameworkLogger.DefaultLogLevel":   h.LogLevel,
+		"ApplicationLogger.DefaultLogLevel": h.LogLevel,
+	}
+
+	for path, value := range h.Properties {
+		properties[path] = value
+	}
+
+	return properties
+}
+
+func (h *Harness) Container() *ioc.ComponentContainer {
+	return h.container
+}
+
+func (h *Harness) Component(name string) interface{} {
+
+	if h.container == nil {
+		return nil
+	}
+
+	component, err := h.container.ComponentByName(name)
+
+	if err != nil {
+		return nil
+	}
+
+	return component.Instance
+}
+
+func (h *Harness) Reload() (*ioc.ReloadReport, error) {
+
+	if h.container == nil {
+		return nil, errors.New("Harness not started")
+	}
+
+	return h.initiator.Reload(h.container)
+}
+
+func (h *Harness) Shutdown() *ioc.ShutdownReport {
+
+	if h.container == nil {
+		return nil
+	}
+
+	report := h.initiator.Shutdown(h.container)
+	h.container = nil
+
+	return report
+}
@@ -0,0 +1,165 @@
+package testharness
+
+import (
+	"errors"
+	"github.com/wolferton/quilt/config"
+	"github.com/wolferton/quilt/ioc"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type store interface {
+	Load(id string) string
+}
+
+type realStore struct{}
+
+func (rs *realStore) Load(id string) string {
+	return "real"
+}
+
+type fakeStore struct{}
+
+func (fs *fakeStore) Load(id string) string {
+	return "fake"
+}
+
+type service struct {
+	Store store
+}
+
+func customComponents() []*ioc.ProtoComponent {
+
+	proto := ioc.CreateProtoComponent(new(service), "service")
+	proto.AddDependency("Store", "store")
+
+	return []*ioc.ProtoComponent{proto, ioc.CreateProtoComponent(new(realStore), "store")}
+}
+
+type failingStore struct {
+	realStore
+}
+
+func (fs *failingStore) StartComponent() error {
+	return errors.New("unavailable")
+}
+
+func setQuiltHome(t *testing.T) {
+
+	_, file, _, _ := runtime.Caller(0)
+
+	t.Setenv("QUILT_HOME", filepath.Join(filepath.Dir(file), "..", ".."))
+}
+
+func TestOverrideComponent(t *testing.T) {
+
+	setQuiltHome(t)
+
+	h := NewHarness(customComponents())
+	h.Override("store", new(fakeStore))
+
+	if err := h.Start(); err != nil {
+		t.Fatalf("Unexpected error starting harness: %s", err)
+	}
+
+	if loaded := h.Component("service").(*service).Store.Load("1"); loaded != "fake" {
+		t.Errorf("Expected the overridden store to be injected, got %s", loaded)
+	}
+
+	if report := h.Shutdown(); !report.Clean() {
+		t.Errorf("Expected a clean shutdown")
+	}
+
+	h = NewHarness(customComponents())
+	h.Override("stor", new(fakeStore))
+
+	if err := h.Start(); err == nil {
+		t.Errorf("Expected an error when overriding an unknown component")
+	}
+
+}
+
+func TestFailedStartCanBeRetried(t *testing.T) {
+
+	setQuiltHome(t)
+
+	h := NewHarness(customComponents())
+	h.Override("store", new(failingStore))
+
+	if err := h.Start(); err == nil {
+		t.Fatalf("Expected an error when a component fails to start")
+	}
+
+	if h.Container() != nil {
+		t.Errorf("Did not expect a container to be retained after a failed start")
+	}
+
+	h.overrides = nil
+	h.Override("store", new(fakeStore))
+
+	if err := h.Start(); err != nil {
+		t.Fatalf("Expected the harness to start after a failed attempt, got %s", err)
+	}
+
+	h.Shutdown()
+}
+
+func TestMalformedConfigRejectedOnReload(t *testing.T) {
+
+	setQuiltHome(t)
+
+	configFile := filepath.Join(t.TempDir(), "app.json")
+	ioutil.WriteFile(configFile, []byte(`{"App": {"Name": "orders"}}`), 0600)
+
+	h := NewHarness(customComponents(), configFile)
+
+	if err := h.Start(); err != nil {
+		t.Fatalf("Unexpected error starting harness: %s", err)
+	}
+
+	defer h.Shutdown()
+
+	if err := os.Truncate(configFile, 10); err != nil {
+		t.Fatalf("Unable to truncate config file: %s", err)
+	}
+
+	if _, err := h.Reload(); err == nil {
+		t.Errorf("Expected an error when reloading a malformed config file")
+	}
+
+	if h.Component("service").(*service).Store.Load("1") != "real" {
+		t.Errorf("Expected the container to keep running on the previous configuration")
+	}
+}
+
+func TestSchemaViolationRejectedOnReload(t *testing.T) {
+
+	setQuiltHome(t)
+
+	configFile := filepath.Join(t.TempDir(), "app.json")
+	ioutil.WriteFile(configFile, []byte(`{"App": {"Name": "orders"}}`), 0600)
+
+	h := NewHarness(customComponents(), configFile)
+
+	if err := h.Start(); err != nil {
+		t.Fatalf("Unexpected error starting harness: %s", err)
+	}
+
+	defer h.Shutdown()
+
+	ioutil.WriteFile(configFile, []byte(`{"ComponentContainer": {"ShutdownTimout": "5s"}}`), 0600)
+
+	_, err := h.Reload()
+
+	if _, isSchemaError := err.(*config.SchemaErrors); !isSchemaError {
+		t.Fatalf("Expected schema errors when reloading a misspelt setting, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "ComponentContainer.ShutdownTimout") {
+		t.Errorf("Expected the misspelt key to be reported, got %s", err)
+	}
+}
@@ -0,0 +1,179 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const injectTagName = "quilt"
+const injectTagValue = "inject"
+
+// wiring holds a proto's explicit dependencies together with those found by autowiring. It is rebuilt on every
+// Populate so that autowired fields reflect the components available at the time.
+type wiring struct {
+	dependencies map[string]string
+	collections  map[string][]string
+}
+
+func newWiring(proto *ProtoComponent) *wiring {
+
+	w := &wiring{copyStringMap(proto.Dependencies), make(map[string][]string)}
+
+	for field, members := range proto.CollectionDependencies {
+		w.collections[field] = append([]string{}, members...)
+	}
+
+	return w
+}
+
+func (cc *ComponentContainer) wiringFor(proto *ProtoComponent) *wiring {
+
+	if w := cc.wiring[proto]; w != nil {
+		return w
+	}
+
+	return newWiring(proto)
+}
+
+func (cc *ComponentContainer) autowire() []error {
+
+	problems := []error{}
+	cc.wiring = make(map[*ProtoComponent]*wiring)
+
+	for _, name := range cc.sortedProtoNames() {
+
+		proto := cc.protoComponents[name]
+		w := newWiring(proto)
+
+		cc.wiring[proto] = w
+		problems = cc.autowireProto(proto, w, problems)
+	}
+
+	return problems
+}
+
+func (cc *ComponentContainer) autowireProto(proto *ProtoComponent, w *wiring, problems []error) []error {
+
+	component := proto.Component
+
+	if !isStructPointer(component.Instance) {
+		return problems
+	}
+
+	structType := reflect.TypeOf(component.Instance).Elem()
+
+	for i := 0; i < structType.NumField(); i++ {
+
+		field := structType.Field(i)
+
+		if field.Tag.Get(injectTagName) != injectTagValue {
+			continue
+		}
+
+		if _, explicit := w.dependencies[field.Name]; explicit {
+			continue
+		}
+
+		if _, explicit := w.collections[field.Name]; explicit {
+			continue
+		}
+
+		if field.PkgPath != "" {
+			problems = append(problems, fmt.Errorf("Unable to autowire %s.%s as the field is not exported", component.Name, field.Name))
+			continue
+		}
+
+		if isCollectionType(field.Type) {
+			members := cc.componentsAssignableTo(field.Type.Elem(), component.Name)
+			cc.FrameworkLogger.LogTracef("Autowiring %v into %s.%s", members, component.Name, field.Name)
+			w.collections[field.Name] = members
+
+			continue
+		}
+
+		candidates := cc.componentsAssignableTo(field.Type, component.Name)
+
+		switch len(candidates) {
+		case 0:
+			problems = append(problems, fmt.Errorf("No component of a type assignable to %s available to autowire %s.%s", field.Type, component.Name, field.Name))
+		case 1:
+			cc.FrameworkLogger.LogTracef("Autowiring %s into %s.%s", candidates[0], component.Name, field.Name)
+			w.dependencies[field.Name] = candidates[0]
+		default:
+			problems = append(problems, fmt.Errorf("Unable to autowire %s.%s as more than one component is assignable to %s: %s", component.Name, field.Name, field.Type, strings.Join(candidates, ", ")))
+		}
+	}
+
+	return problems
+}
+
+func (cc *ComponentContainer) componentsAssignableTo(targetType reflect.Type, excludeName string) []string {
+
+	names := []string{}
+
+	for _, components := range cc.componentsByType {
+
+		if !reflect.TypeOf(components[0].Instance).AssignableTo(targetType) {
+			continue
+		}
+
+		for _, component := range components {
+			if component.Name != excludeName {
+				names = append(names, component.Name)
+			}
+		}
+	}
+
+	names = cc.parentComponentsAssignableTo(targetType, names)
+
+	sort.Strings(names)
+
+	return names
+}
+
+func isCollectionType(t reflect.Type) bool {
+
+	switch t.Kind() {
+	case reflect.Slice:
+		return true
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String
+	default:
+		return false
+	}
+}
+
+func (cc *ComponentContainer) injectCollection(componentName string, instance interface{}, fieldName string, members []string) error {
+
+	targetField := reflect.ValueOf(instance).Elem().FieldByName(fieldName)
+	fieldType := targetField.Type()
+
+	var collection reflect.Value
+
+	if fieldType.Kind() == reflect.Slice {
+		collection = reflect.MakeSlice(fieldType, 0, len(members))
+	} else {
+		collection = reflect.MakeMap(fieldType)
+	}
+
+	for _, memberName := range members {
+
+		memberValue, err := cc.dependencyValue(componentName, fieldName, memberName, fieldType.Elem())
+
+		if err != nil {
+			return err
+		}
+
+		if fieldType.Kind() == reflect.Slice {
+			collection = reflect.Append(collection, memberValue)
+		} else {
+			collection.SetMapIndex(reflect.ValueOf(memberName).Convert(fieldType.Key()), memberValue)
+		}
+	}
+
+	targetField.Set(collection)
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package ioc
+
+import (
+	"reflect"
+)
+
+func (cc *ComponentContainer) NewChild() *ComponentContainer {
+
+	child := new(ComponentContainer)
+	child.parent = cc
+	child.protoComponents = make(map[string]*ProtoComponent)
+	child.excluded = make(map[string]*excludedComponent)
+	child.activeProfiles = make(map[string]bool)
+	child.settings = cc.settings
+	child.health = new(healthMonitor)
+	child.FrameworkLogger = cc.FrameworkLogger
+	child.configAccessor = cc.configAccessor
+
+	for profile := range cc.activeProfiles {
+		child.activeProfiles[profile] = true
+	}
+
+	return child
+}
+
+func (cc *ComponentContainer) Parent() *ComponentContainer {
+	return cc.parent
+}
+
+func (cc *ComponentContainer) findProto(name string) *ProtoComponent {
+
+	if proto := cc.protoComponents[name]; proto != nil {
+		return proto
+	}
+
+	if cc.parent != nil {
+		return cc.parent.findProto(name)
+	}
+
+	return nil
+}
+
+func (cc *ComponentContainer) inheritedDependencyValue(depName string, fieldType reflect.Type) (reflect.Value, error) {
+
+	if owner := cc.prototypeOwner(depName); owner != nil {
+
+		if fieldType == componentFactoryType {
+			return reflect.ValueOf(&ComponentFactory{owner, depName}), nil
+		}
+
+		instance, err := owner.NewInstance(depName)
+
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(instance), nil
+	}
+
+	component, err := cc.ComponentByName(depName)
+
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	return reflect.ValueOf(component.Instance), nil
+}
+
+func (cc *ComponentContainer) prototypeOwner(name string) *ComponentContainer {
+
+	cc.mutex.Lock()
+	prototype := cc.prototypes[name]
+	local := cc.protoComponents[name] != nil
+	cc.mutex.Unlock()
+
+	if prototype != nil {
+		return cc
+	}
+
+	if !local && cc.parent != nil {
+		return cc.parent.prototypeOwner(name)
+	}
+
+	return nil
+}
+
+func (cc *ComponentContainer) inheritDecorators(decorators []*namedDecorator) []*namedDecorator {
+
+	if cc.parent == nil {
+		return decorators
+	}
+
+	for _, nd := range cc.parent.decorators {
+
+		if nd.name == containerDecoratorComponentName || cc.protoComponents[nd.name] != nil {
+			continue
+		}
+
+		decorators = append(decorators, nd)
+	}
+
+	return decorators
+}
+
+func (cc *ComponentContainer) parentComponentsAssignableTo(targetType reflect.Type, names []string) []string {
+
+	if cc.parent == nil {
+		return names
+	}
+
+	for _, name := range cc.parent.componentsAssignableTo(targetType, "") {
+
+		if cc.protoComponents[name] == nil {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
@@ -1,13 +1,35 @@
 package ioc
 
+import (
+	"github.com/wolferton/quilt/config"
+)
+
 const FrameworkPrefix = "quilt"
 
+const (
+	SingletonScope = iota
+	PrototypeScope
+)
+
 type ProtoComponent struct {
-	Component      *Component
-	Dependencies   map[string]string
-	ConfigPromises map[string]string
+	Component              *Component
+	Dependencies           map[string]string
+	CollectionDependencies map[string][]string
+	ConfigPromises         map[string]string
+	Properties             map[string]interface{}
+	ToleratedCycles        map[string]bool
+	Scope                  int
+	Lazy                   bool
+	Conditions             []ComponentCondition
+	ConfigRoots            []string
+	Builder                ComponentBuilder
 }
 
+// ComponentBuilder creates a new instance of a component from configuration. It is used in place of a zero value
+// when a component is rebuilt after a configuration reload and the component has state that is not populated
+// from configuration, properties or dependencies.
+type ComponentBuilder func(ca *config.ConfigAccessor) (interface{}, error)
+
 func (pc *ProtoComponent) AddDependency(fieldName, componentName string) {
 
 	if pc.Dependencies == nil {
@@ -17,6 +39,28 @@ func (pc *ProtoComponent) AddDependency(fieldName, componentName string) {
 	pc.Dependencies[fieldName] = componentName
 }
 
+func (pc *ProtoComponent) AddCollectionDependency(fieldName string, componentNames []string) {
+
+	if pc.CollectionDependencies == nil {
+		pc.CollectionDependencies = make(map[string][]string)
+	}
+
+	pc.CollectionDependencies[fieldName] = componentNames
+}
+
+func (pc *ProtoComponent) AddCondition(condition ComponentCondition) {
+	pc.Conditions = append(pc.Conditions, condition)
+}
+
+func (pc *ProtoComponent) TolerateCycle(fieldName string) {
+
+	if pc.ToleratedCycles == nil {
+		pc.ToleratedCycles = make(map[string]bool)
+	}
+
+	pc.ToleratedCycles[fieldName] = true
+}
+
 func (pc *ProtoComponent) AddConfigPromise(fieldName, configPath string) {
 
 	if pc.ConfigPromises == nil {
@@ -26,6 +70,21 @@ func (pc *ProtoComponent) AddConfigPromise(fieldName, configPath string) {
 	pc.ConfigPromises[fieldName] = configPath
 }
 
+// AddConfigRoot records that the component's fields are populated from the object at the supplied config path.
+// Any change beneath that path causes the component to be rebuilt when configuration is reloaded.
+func (pc *ProtoComponent) AddConfigRoot(configPath string) {
+	pc.ConfigRoots = append(pc.ConfigRoots, configPath)
+}
+
+func (pc *ProtoComponent) AddProperty(fieldName string, value interface{}) {
+
+	if pc.Properties == nil {
+		pc.Properties = make(map[string]interface{})
+	}
+
+	pc.Properties[fieldName] = value
+}
+
 func CreateProtoComponent(componentInstance interface{}, componentName string) *ProtoComponent {
 
 	proto := new(ProtoComponent)
@@ -0,0 +1,134 @@
+package ioc
+
+import (
+	"fmt"
+	"github.com/wolferton/quilt/config"
+	"os"
+	"strings"
+)
+
+type ComponentCondition interface {
+	Satisfied(ca *config.ConfigAccessor, activeProfiles map[string]bool) bool
+	Describe() string
+}
+
+func ConfigPathExists(path string) ComponentCondition {
+	return &configPathCondition{path, false, nil}
+}
+
+func ConfigValueEquals(path string, value interface{}) ComponentCondition {
+	return &configPathCondition{path, true, value}
+}
+
+func EnvironmentVariableSet(name string) ComponentCondition {
+	return &environmentCondition{name}
+}
+
+func ProfileActive(name string) ComponentCondition {
+	return &profileCondition{name}
+}
+
+type configPathCondition struct {
+	path         string
+	compareValue bool
+	value        interface{}
+}
+
+func (cpc *configPathCondition) Satisfied(ca *config.ConfigAccessor, activeProfiles map[string]bool) bool {
+
+	if !cpc.compareValue {
+		return ca.PathExists(cpc.path)
+	}
+
+	actual := ca.Value(cpc.path)
+
+	return actual != nil && fmt.Sprintf("%v", actual) == fmt.Sprintf("%v", cpc.value)
+}
+
+func (cpc *configPathCondition) Describe() string {
+
+	if cpc.compareValue {
+		return fmt.Sprintf("config %s equals %v", cpc.path, cpc.value)
+	}
+
+	return fmt.Sprintf("config %s exists", cpc.path)
+}
+
+type environmentCondition struct {
+	name string
+}
+
+func (ec *environmentCondition) Satisfied(ca *config.ConfigAccessor, activeProfiles map[string]bool) bool {
+	_, set := os.LookupEnv(ec.name)
+
+	return set
+}
+
+func (ec *environmentCondition) Describe() string {
+	return fmt.Sprintf("environment variable %s is set", ec.name)
+}
+
+type profileCondition struct {
+	name string
+}
+
+func (pc *profileCondition) Satisfied(ca *config.ConfigAccessor, activeProfiles map[string]bool) bool {
+	return activeProfiles[pc.name]
+}
+
+func (pc *profileCondition) Describe() string {
+	return fmt.Sprintf("profile %s is active", pc.name)
+}
+
+func (cc *ComponentContainer) ActivateProfiles(profiles []string) {
+
+	for _, profile := range profiles {
+
+		profile = strings.TrimSpace(profile)
+
+		if profile != "" {
+			cc.FrameworkLogger.LogDebugf("Activating profile %s", profile)
+			cc.activeProfiles[profile] = true
+		}
+	}
+}
+
+type excludedComponent struct {
+	proto     *ProtoComponent
+	condition ComponentCondition
+}
+
+func (cc *ComponentContainer) excludeUnsatisfiedComponents() {
+
+	for name, ec := range cc.excluded {
+
+		if cc.protoComponents[name] == nil {
+			cc.protoComponents[name] = ec.proto
+		}
+
+		delete(cc.excluded, name)
+	}
+
+	for _, name := range cc.sortedProtoNames() {
+
+		proto := cc.protoComponents[name]
+
+		if condition := unsatisfiedCondition(proto, cc.configAccessor, cc.activeProfiles); condition != nil {
+			cc.FrameworkLogger.LogDebugf("Excluding component %s as condition not met (%s)", name, condition.Describe())
+			cc.excluded[name] = &excludedComponent{proto, condition}
+			delete(cc.protoComponents, name)
+		}
+	}
+}
+
+func unsatisfiedCondition(proto *ProtoComponent, ca *config.ConfigAccessor, activeProfiles map[string]bool) ComponentCondition {
+
+	for _, condition := range proto.Conditions {
+
+		if !condition.Satisfied(ca, activeProfiles) {
+			return condition
+		}
+	}
+
+	return nil
+}
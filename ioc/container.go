@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"github.com/wolferton/quilt/config"
 	"github.com/wolferton/quilt/logging"
-	"os"
 	"reflect"
+	"sync"
 	"time"
 )
 
@@ -14,28 +14,92 @@ const containerDecoratorComponentName = "quiltContainerDecorator"
 const containerComponentName = "quiltContainer"
 
 type ComponentContainer struct {
-	allComponents    map[string]*Component
-	protoComponents  map[string]*ProtoComponent
-	componentsByType map[string][]interface{}
-	FrameworkLogger  logging.Logger
-	configAccessor   *config.ConfigAccessor
-	startable        []*Component
-	stoppable        []*Component
-	blocker          []*Component
-	accessible       []*Component
+	parent            *ComponentContainer
+	allComponents     map[string]*Component
+	protoComponents   map[string]*ProtoComponent
+	excluded          map[string]*excludedComponent
+	prototypes        map[string]*ProtoComponent
+	deferred          map[string]*ProtoComponent
+	activeProfiles    map[string]bool
+	settings          *ContainerSettings
+	startupReport     *StartupReport
+	shutdownReport    *ShutdownReport
+	componentsByType  map[string][]*Component
+	FrameworkLogger   logging.Logger
+	configAccessor    *config.ConfigAccessor
+	decorators        []*namedDecorator
+	decoratedBy       map[string][]string
+	pendingPrototypes []*Component
+	initialising      map[string]bool
+	starting          map[string]chan bool
+	lazyStarts        []*Component
+	populated         bool
+	started           bool
+	accessAllowed     bool
+	mutex             sync.Mutex
+	reloadMutex       sync.Mutex
+	wiring            map[*ProtoComponent]*wiring
+	startable         []*Component
+	stoppable         []*Component
+	blocker           []*Component
+	accessible        []*Component
+	cleanable         []*Component
+	healthReporters   []*Component
+	health            *healthMonitor
 }
 
 func (cc *ComponentContainer) AllComponents() map[string]*Component {
-	return cc.allComponents
+
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	components := make(map[string]*Component, len(cc.allComponents))
+
+	for name, component := range cc.allComponents {
+		components[name] = component
+	}
+
+	return components
+}
+
+func (cc *ComponentContainer) lifecycleComponents(components *[]*Component) []*Component {
+
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	return append([]*Component{}, (*components)...)
 }
 
 func (cc *ComponentContainer) AddProto(proto *ProtoComponent) {
 
 	cc.FrameworkLogger.LogTracef("Adding proto %s", proto.Component.Name)
 
+	delete(cc.excluded, proto.Component.Name)
 	cc.protoComponents[proto.Component.Name] = proto
 }
 
+func (cc *ComponentContainer) ReplaceProto(proto *ProtoComponent) error {
+
+	name := proto.Component.Name
+
+	if ec := cc.excluded[name]; ec != nil {
+		cc.FrameworkLogger.LogDebugf("Replacing excluded proto %s", name)
+		ec.proto = proto
+
+		return nil
+	}
+
+	if cc.protoComponents[name] == nil {
+		return fmt.Errorf("No component named %s to replace", name)
+	}
+
+	cc.FrameworkLogger.LogDebugf("Replacing proto %s", name)
+
+	cc.protoComponents[name] = proto
+
+	return nil
+}
+
 func (cc *ComponentContainer) WrapAndAddProto(name string, instance interface{}) {
 	p := CreateProtoComponent(instance, name)
 	cc.AddProto(p)
@@ -48,115 +112,155 @@ func (cc *ComponentContainer) AddProtos(protos []*ProtoComponent) {
 }
 
 func (cc *ComponentContainer) FindByType(typeName string) []interface{} {
-	return cc.componentsByType[typeName]
+	return cc.findByType(typeName, make(map[string]bool))
 }
 
-func (cc *ComponentContainer) StartComponents() error {
+func (cc *ComponentContainer) findByType(typeName string, hidden map[string]bool) []interface{} {
 
-	defer func() {
-		if r := recover(); r != nil {
-			cc.FrameworkLogger.LogErrorfWithTrace("Panic recovered while starting components components %s", r)
-			os.Exit(-1)
-		}
-	}()
+	cc.mutex.Lock()
 
-	for _, component := range cc.startable {
+	components := cc.componentsByType[typeName]
+	found := make([]*Component, 0, len(components))
 
-		startable := component.Instance.(Startable)
+	for _, component := range components {
 
-		err := startable.StartComponent()
+		if hidden[component.Name] {
+			continue
+		}
 
-		if err != nil {
-			message := fmt.Sprintf("Unable to start %s: %s", component.Name, err)
-			return errors.New(message)
+		if lazy := cc.deferred[component.Name]; lazy != nil {
+
+			if _, err := cc.initialiseLazyComponent(lazy); err != nil {
+				cc.FrameworkLogger.LogErrorf("Unable to initialise lazy component %s: %s", component.Name, err)
+				continue
+			}
 		}
 
+		found = append(found, component)
 	}
 
-	if len(cc.blocker) != 0 {
-		err := cc.waitForBlockers(5*time.Second, 12, 0)
+	for name := range cc.protoComponents {
+		hidden[name] = true
+	}
 
-		if err != nil {
-			return err
-		}
+	cc.mutex.Unlock()
 
+	if err := cc.startLazyComponents(); err != nil {
+		cc.FrameworkLogger.LogErrorf("Unable to start lazy component: %s", err)
 	}
 
-	for _, component := range cc.accessible {
+	instances := make([]interface{}, 0, len(found))
 
-		accessible := component.Instance.(Accessible)
-		err := accessible.AllowAccess()
+	for _, component := range found {
 
-		if err != nil {
-			return err
+		cc.mutex.Lock()
+		starting := cc.starting[component.Name]
+		cc.mutex.Unlock()
+
+		if starting != nil {
+			<-starting
 		}
 
+		cc.mutex.Lock()
+		registered := cc.allComponents[component.Name] == component
+		cc.mutex.Unlock()
+
+		if registered {
+			instances = append(instances, component.Instance)
+		}
 	}
 
-	return nil
-}
+	if cc.parent != nil {
+		instances = append(instances, cc.parent.findByType(typeName, hidden)...)
+	}
 
-func (cc *ComponentContainer) waitForBlockers(retestInterval time.Duration, maxTries int, warnAfterTries int) error {
+	return instances
+}
 
-	var names []string
+func (cc *ComponentContainer) StartComponents() error {
 
-	for i := 0; i < maxTries; i++ {
+	cc.mutex.Lock()
+	cc.started = true
+	cc.mutex.Unlock()
 
-		notReady, cNames := cc.countBlocking(i > warnAfterTries)
-		names = cNames
+	var deadline time.Time
 
-		if notReady == 0 {
-			return nil
-		} else {
-			time.Sleep(retestInterval)
-		}
+	if cc.settings.StartTimeout > 0 {
+		deadline = time.Now().Add(cc.settings.StartTimeout)
 	}
 
-	message := fmt.Sprintf("Startup blocked by %v", names)
+	err := cc.startAll(deadline)
 
-	return errors.New(message)
-
-}
+	if err != nil {
+		return err
+	}
 
-func (cc *ComponentContainer) ShutdownComponents() error {
+	if len(cc.lifecycleComponents(&cc.blocker)) != 0 {
+		err := cc.waitForBlockers(cc.settings.BlockerRetestInterval, cc.settings.BlockerMaxTries, 0)
 
-	for _, c := range cc.stoppable {
+		if err != nil {
+			return err
+		}
 
-		s := c.Instance.(Stoppable)
-		s.PrepareToStop()
 	}
 
-	cc.waitForReadyToStop(5*time.Second, 10, 3)
+	cc.mutex.Lock()
+	cc.accessAllowed = true
+	cc.mutex.Unlock()
 
-	for _, c := range cc.stoppable {
+	for _, component := range cc.lifecycleComponents(&cc.accessible) {
 
-		s := c.Instance.(Stoppable)
-		err := s.Stop()
+		accessible := component.Instance.(Accessible)
+		err := accessible.AllowAccess()
 
 		if err != nil {
-			cc.FrameworkLogger.LogErrorf("%s did not stop cleanly %s", c.Name, err)
+			return err
 		}
 
 	}
 
+	cc.startHealthMonitor()
+
 	return nil
+}
+
+func (cc *ComponentContainer) startComponent(component *Component) (err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			cc.FrameworkLogger.LogErrorfWithTrace("Panic recovered while starting component %s %s", component.Name, r)
+			err = &ComponentStartError{component.Name, fmt.Errorf("panic: %v", r)}
+		}
+	}()
+
+	startable := component.Instance.(Startable)
+
+	if startErr := startable.StartComponent(); startErr != nil {
+		return &ComponentStartError{component.Name, startErr}
+	}
 
+	return nil
 }
 
-func (cc *ComponentContainer) waitForReadyToStop(retestInterval time.Duration, maxTries int, warnAfterTries int) {
+func (cc *ComponentContainer) waitForBlockers(retestInterval time.Duration, maxTries int, warnAfterTries int) error {
+
+	var names []string
 
 	for i := 0; i < maxTries; i++ {
 
-		notReady := cc.countNotReady(i > warnAfterTries)
+		notReady, cNames := cc.countBlocking(i > warnAfterTries)
+		names = cNames
 
 		if notReady == 0 {
-			return
+			return nil
 		} else {
 			time.Sleep(retestInterval)
 		}
 	}
 
-	cc.FrameworkLogger.LogFatalf("Some components not ready to stop, stopping anyway")
+	message := fmt.Sprintf("Startup blocked by %v", names)
+
+	return errors.New(message)
 
 }
 
@@ -165,7 +269,7 @@ func (cc *ComponentContainer) countBlocking(warn bool) (int, []string) {
 	notReady := 0
 	names := []string{}
 
-	for _, c := range cc.blocker {
+	for _, c := range cc.lifecycleComponents(&cc.blocker) {
 		ab := c.Instance.(AccessibilityBlocker)
 
 		block, err := ab.BlockAccess()
@@ -188,53 +292,72 @@ func (cc *ComponentContainer) countBlocking(warn bool) (int, []string) {
 	return notReady, names
 }
 
-func (cc *ComponentContainer) countNotReady(warn bool) int {
+func (cc *ComponentContainer) Populate() (err error) {
 
-	notReady := 0
+	defer func() {
+		if r := recover(); r != nil {
+			cc.FrameworkLogger.LogErrorfWithTrace("Panic recovered while configuring components %s", r)
+			err = fmt.Errorf("Panic recovered while configuring components: %v", r)
+		}
+	}()
 
-	for _, c := range cc.stoppable {
-		s := c.Instance.(Stoppable)
+	decorators := make([]*namedDecorator, 1)
 
-		ready, err := s.ReadyToStop()
+	containerDecorator := new(ContainerDecorator)
+	containerDecorator.container = cc
 
-		if !ready {
-			notReady += 1
+	decorators[0] = &namedDecorator{containerDecoratorComponentName, containerDecorator}
 
-			if warn {
-				if err != nil {
-					cc.FrameworkLogger.LogWarnf("%s is not ready to stop: %s", c.Name, err)
-				} else {
-					cc.FrameworkLogger.LogWarnf("%s is not ready to stop (no reason given)", c.Name)
-				}
-
-			}
-		}
+	err = cc.loadSettings()
 
+	if err != nil {
+		return err
 	}
 
-	return notReady
-}
-
-func (cc *ComponentContainer) Populate() error {
+	cc.excludeUnsatisfiedComponents()
 
-	defer func() {
-		if r := recover(); r != nil {
-			cc.FrameworkLogger.LogErrorfWithTrace("Panic recovered while configuring components %s", r)
-			os.Exit(-1)
+	cc.allComponents = make(map[string]*Component)
+	cc.decoratedBy = make(map[string][]string)
+	cc.prototypes = make(map[string]*ProtoComponent)
+	cc.componentsByType = make(map[string][]*Component)
+	cc.populated = false
+	cc.started = false
+	cc.accessAllowed = false
+	cc.pendingPrototypes = nil
+	cc.startable = nil
+	cc.stoppable = nil
+	cc.blocker = nil
+	cc.accessible = nil
+	cc.cleanable = nil
+	cc.healthReporters = nil
+
+	for _, name := range cc.sortedProtoNames() {
+
+		proto := cc.protoComponents[name]
+
+		if proto.Scope == PrototypeScope {
+			cc.prototypes[name] = proto
+		} else {
+			cc.mapComponentToType(proto.Component)
 		}
-	}()
+	}
 
-	decorators := make([]ComponentDecorator, 1)
+	problems := cc.autowire()
+	problems = cc.validateProtos(problems)
 
-	containerDecorator := new(ContainerDecorator)
-	containerDecorator.container = cc
+	if len(problems) > 0 {
+		return &ValidationErrors{problems}
+	}
 
-	decorators[0] = containerDecorator
+	ordered, err := cc.dependencyOrder()
 
-	cc.allComponents = make(map[string]*Component)
-	cc.componentsByType = make(map[string][]interface{})
+	if err != nil {
+		return err
+	}
+
+	eager := cc.deferLazyComponents(ordered)
 
-	for _, protoComponent := range cc.protoComponents {
+	for _, protoComponent := range eager {
 
 		component := protoComponent.Component
 
@@ -243,81 +366,221 @@ func (cc *ComponentContainer) Populate() error {
 
 	}
 
-	err := cc.resolveDependenciesAndConfig()
+	cc.decorators, err = cc.orderDecorators(cc.inheritDecorators(decorators))
+
+	if err != nil {
+		return err
+	}
+
+	err = cc.resolveDependenciesAndConfig(eager)
 
 	if err != nil {
-		cc.FrameworkLogger.LogFatalf(err.Error())
-		cc.FrameworkLogger.LogInfof("Aborting startup")
-		os.Exit(-1)
+		return err
 	}
 
-	cc.decorateComponents(decorators)
+	cc.decorateComponents()
+
+	problems = cc.validateComponents(eager)
+
+	if len(problems) > 0 {
+		return &ValidationErrors{problems}
+	}
+
+	cc.populated = true
+
+	return nil
+}
+
+func (cc *ComponentContainer) resolveDependenciesAndConfig(eager []*ProtoComponent) error {
+
+	for _, proto := range eager {
+
+		err := cc.resolveComponent(proto, proto.Component.Instance)
+
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-func (cc *ComponentContainer) resolveDependenciesAndConfig() error {
+func (cc *ComponentContainer) resolveComponent(proto *ProtoComponent, instance interface{}) error {
 
 	fl := cc.FrameworkLogger
+	targetReflect := reflect.ValueOf(instance).Elem()
 
-	for _, proto := range cc.protoComponents {
+	if err := applyProperties(proto, instance); err != nil {
+		return err
+	}
 
-		for fieldName, depName := range proto.Dependencies {
+	w := cc.wiringFor(proto)
 
-			fl.LogTracef("%s needs %s", proto.Component.Name, depName)
+	for _, fieldName := range sortedKeys(w.dependencies) {
 
-			requiredComponent := cc.allComponents[depName]
+		depName := w.dependencies[fieldName]
 
-			if requiredComponent == nil {
-				message := fmt.Sprintf("No component named %s available (required by %s.%s)", depName, proto.Component.Name, fieldName)
-				return errors.New(message)
-			}
+		fl.LogTracef("%s needs %s", proto.Component.Name, depName)
 
-			requiredInstance := requiredComponent.Instance
+		targetField := targetReflect.FieldByName(fieldName)
+		requiredValue, err := cc.dependencyValue(proto.Component.Name, fieldName, depName, targetField.Type())
 
-			targetReflect := reflect.ValueOf(proto.Component.Instance).Elem()
+		if err != nil {
+			return err
+		}
 
-			defer func() {
-				if r := recover(); r != nil {
-					fl.LogFatalf("Problem setting %s.%s: %s ", proto.Component.Name, fieldName, r)
-				}
-			}()
+		targetField.Set(requiredValue)
+	}
+
+	for _, fieldName := range w.collectionFields() {
+
+		members := w.collections[fieldName]
+
+		fl.LogTracef("%s needs %v", proto.Component.Name, members)
+
+		err := cc.injectCollection(proto.Component.Name, instance, fieldName, members)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, fieldName := range sortedKeys(proto.ConfigPromises) {
+
+		configPath := proto.ConfigPromises[fieldName]
+
+		fl.LogTracef("%s.%s needs %s", proto.Component.Name, fieldName, configPath)
+
+		err := cc.configAccessor.SetField(fieldName, configPath, instance)
+
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+}
+
+func (cc *ComponentContainer) dependencyValue(componentName, fieldName, depName string, fieldType reflect.Type) (reflect.Value, error) {
+
+	if prototype := cc.prototypes[depName]; prototype != nil {
+
+		if fieldType == componentFactoryType {
+			return reflect.ValueOf(&ComponentFactory{cc, depName}), nil
+		}
+
+		component, err := cc.createPrototypeInstance(prototype)
+
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(component.Instance), nil
+	}
+
+	if lazy := cc.deferred[depName]; lazy != nil {
 
-			targetReflect.FieldByName(fieldName).Set(reflect.ValueOf(requiredInstance))
+		component, err := cc.initialiseLazyComponent(lazy)
+
+		if err != nil {
+			return reflect.Value{}, err
 		}
 
-		for fieldName, configPath := range proto.ConfigPromises {
-			fl.LogTracef("%s needs %s", proto.Component.Name, fieldName, configPath)
+		return reflect.ValueOf(component.Instance), nil
+	}
+
+	requiredComponent := cc.allComponents[depName]
+
+	if requiredComponent == nil && cc.parent != nil && cc.protoComponents[depName] == nil {
+		return cc.parent.inheritedDependencyValue(depName, fieldType)
+	}
+
+	if requiredComponent == nil {
+		return reflect.Value{}, &MissingDependencyError{componentName, fieldName, depName}
+	}
+
+	return reflect.ValueOf(requiredComponent.Instance), nil
+}
+
+func (cc *ComponentContainer) validateComponents(eager []*ProtoComponent) []error {
 
-			cc.configAccessor.SetField(fieldName, configPath, proto.Component.Instance)
+	problems := []error{}
 
+	for _, proto := range eager {
+
+		if err := validateComponent(proto.Component); err != nil {
+			problems = append(problems, err)
 		}
+	}
 
+	for _, component := range cc.pendingPrototypes {
+
+		if err := validateComponent(component); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	cc.pendingPrototypes = nil
+
+	return problems
+}
+
+func validateComponent(component *Component) error {
+
+	v, validatable := component.Instance.(Validatable)
+
+	if !validatable {
+		return nil
+	}
+
+	if err := v.ValidateComponent(); err != nil {
+		return &ComponentValidationError{component.Name, err}
 	}
 
 	return nil
 }
 
-func (cc *ComponentContainer) decorateComponents(decorators []ComponentDecorator) {
+func (cc *ComponentContainer) decorateComponents() {
 
 	for _, component := range cc.allComponents {
-		for _, decorator := range decorators {
+		cc.decorateComponent(component)
+	}
 
-			if decorator.OfInterest(component) {
-				decorator.DecorateComponent(component, cc)
-			}
+	for _, component := range cc.pendingPrototypes {
+		cc.decorateComponent(component)
+	}
+}
+
+func (cc *ComponentContainer) decorateComponent(component *Component) {
+
+	for _, nd := range cc.decorators {
+
+		if nd.decorator.OfInterest(component) {
+			nd.decorator.DecorateComponent(component, cc)
+			cc.recordDecoration(component.Name, nd.name)
 		}
 	}
+}
 
+func (cc *ComponentContainer) recordDecoration(componentName, decoratorName string) {
+
+	for _, existing := range cc.decoratedBy[componentName] {
+		if existing == decoratorName {
+			return
+		}
+	}
+
+	cc.decoratedBy[componentName] = append(cc.decoratedBy[componentName], decoratorName)
 }
 
-func (cc *ComponentContainer) captureDecorator(component *Component, decorators []ComponentDecorator) []ComponentDecorator {
+func (cc *ComponentContainer) captureDecorator(component *Component, decorators []*namedDecorator) []*namedDecorator {
 
 	decorator, isDecorator := component.Instance.(ComponentDecorator)
 
 	if isDecorator {
 		cc.FrameworkLogger.LogTracef("Found decorator %s", component.Name)
-		return append(decorators, decorator)
+		return append(decorators, &namedDecorator{component.Name, decorator})
 	} else {
 		return decorators
 	}
@@ -325,7 +588,6 @@ func (cc *ComponentContainer) captureDecorator(component *Component, decorators
 
 func (cc *ComponentContainer) addComponent(component *Component) {
 	cc.allComponents[component.Name] = component
-	cc.mapComponentToType(component)
 
 	l := cc.FrameworkLogger
 
@@ -357,6 +619,48 @@ func (cc *ComponentContainer) addComponent(component *Component) {
 		cc.accessible = append(cc.accessible, component)
 	}
 
+	_, cleanable := component.Instance.(Cleanable)
+
+	if cleanable {
+		l.LogTracef("%s is Cleanable", component.Name)
+		cc.cleanable = append(cc.cleanable, component)
+	}
+
+	_, healthReporter := component.Instance.(HealthReporter)
+
+	if healthReporter {
+		l.LogTracef("%s is a HealthReporter", component.Name)
+		cc.healthReporters = append(cc.healthReporters, component)
+	}
+
+}
+
+func (cc *ComponentContainer) removeComponent(component *Component) {
+
+	delete(cc.allComponents, component.Name)
+	delete(cc.decoratedBy, component.Name)
+
+	cc.deferred[component.Name] = cc.protoComponents[component.Name]
+
+	cc.startable = withoutComponent(cc.startable, component)
+	cc.stoppable = withoutComponent(cc.stoppable, component)
+	cc.blocker = withoutComponent(cc.blocker, component)
+	cc.accessible = withoutComponent(cc.accessible, component)
+	cc.cleanable = withoutComponent(cc.cleanable, component)
+	cc.healthReporters = withoutComponent(cc.healthReporters, component)
+}
+
+func withoutComponent(components []*Component, component *Component) []*Component {
+
+	remaining := make([]*Component, 0, len(components))
+
+	for _, c := range components {
+		if c != component {
+			remaining = append(remaining, c)
+		}
+	}
+
+	return remaining
 }
 
 func (cc *ComponentContainer) mapComponentToType(component *Component) {
@@ -368,11 +672,11 @@ func (cc *ComponentContainer) mapComponentToType(component *Component) {
 	componentsOfSameType := cc.componentsByType[typeName]
 
 	if componentsOfSameType == nil {
-		componentsOfSameType = make([]interface{}, 1)
-		componentsOfSameType[0] = component.Instance
+		componentsOfSameType = make([]*Component, 1)
+		componentsOfSameType[0] = component
 		cc.componentsByType[typeName] = componentsOfSameType
 	} else {
-		cc.componentsByType[typeName] = append(componentsOfSameType, component.Instance)
+		cc.componentsByType[typeName] = append(componentsOfSameType, component)
 	}
 
 }
@@ -381,6 +685,10 @@ func NewContainer(loggingManager *logging.ComponentLoggerManager, configAccessor
 
 	container := new(ComponentContainer)
 	container.protoComponents = make(map[string]*ProtoComponent)
+	container.excluded = make(map[string]*excludedComponent)
+	container.activeProfiles = make(map[string]bool)
+	container.settings = defaultContainerSettings()
+	container.health = new(healthMonitor)
 	container.FrameworkLogger = loggingManager.CreateLogger(containerComponentName)
 	container.configAccessor = configAccessor
 
@@ -0,0 +1,1579 @@
+package ioc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wolferton/quilt/config"
+	"github.com/wolferton/quilt/logging"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingComponent struct {
+	Name       string
+	Dependency *recordingComponent
+	Events     *[]string
+}
+
+func (rc *recordingComponent) StartComponent() error {
+	*rc.Events = append(*rc.Events, "start "+rc.Name)
+	return nil
+}
+
+func (rc *recordingComponent) PrepareToStop() {
+}
+
+func (rc *recordingComponent) ReadyToStop() (bool, error) {
+	return true, nil
+}
+
+func (rc *recordingComponent) Stop() error {
+	*rc.Events = append(*rc.Events, "stop "+rc.Name)
+	return nil
+}
+
+func newTestContainer() *ComponentContainer {
+	lm := logging.CreateComponentLoggerManager(logging.Fatal+1, nil)
+	ca := &config.ConfigAccessor{JsonData: map[string]interface{}{}, FrameworkLogger: lm.CreateLogger("config")}
+
+	return NewContainer(lm, ca)
+}
+
+func TestStartAndStopInDependencyOrder(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+
+	names := []string{"a", "b", "c", "d"}
+
+	for _, name := range names {
+		cc.WrapAndAddProto(name, &recordingComponent{Name: name, Events: &events})
+	}
+
+	cc.protoComponents["a"].AddDependency("Dependency", "c")
+	cc.protoComponents["c"].AddDependency("Dependency", "d")
+	cc.protoComponents["d"].AddDependency("Dependency", "b")
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	cc.StartComponents()
+	cc.ShutdownComponents(context.Background())
+
+	expected := []string{"start b", "start d", "start c", "start a", "stop a", "stop c", "stop d", "stop b"}
+
+	if len(events) != len(expected) {
+		t.Fatalf("Expected events %v, got %v", expected, events)
+	}
+
+	for i, e := range expected {
+		if events[i] != e {
+			t.Errorf("Expected events %v, got %v", expected, events)
+			break
+		}
+	}
+
+}
+
+type orderComponent struct {
+	Repo  *orderComponent
+	Cache *orderComponent
+}
+
+func TestCircularDependencyReported(t *testing.T) {
+
+	cc := newTestContainer()
+
+	service := CreateProtoComponent(new(orderComponent), "orderService")
+	service.AddDependency("Repo", "orderRepo")
+
+	repo := CreateProtoComponent(new(orderComponent), "orderRepo")
+	repo.AddDependency("Cache", "orderService")
+
+	cc.AddProtos([]*ProtoComponent{service, repo})
+
+	err := cc.Populate()
+
+	cde, found := err.(*CircularDependencyError)
+
+	if !found {
+		t.Fatalf("Expected a CircularDependencyError, got %v", err)
+	}
+
+	expected := "orderRepo.Cache -> orderService.Repo -> orderRepo"
+
+	if cde.Cycle() != expected {
+		t.Errorf("Expected cycle %s, got %s", expected, cde.Cycle())
+	}
+
+	repo.TolerateCycle("Cache")
+
+	if err := cc.Populate(); err != nil {
+		t.Errorf("Did not expect an error when cycle is tolerated: %s", err)
+	}
+
+}
+
+type autowiredComponent struct {
+	Starter Startable `quilt:"inject"`
+}
+
+func TestAutowireByType(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+
+	target := new(autowiredComponent)
+	cc.WrapAndAddProto("target", target)
+	cc.WrapAndAddProto("a", &recordingComponent{Name: "a", Events: &events})
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	if target.Starter != cc.AllComponents()["a"].Instance {
+		t.Errorf("Expected component a to be autowired")
+	}
+
+	cc = newTestContainer()
+	cc.WrapAndAddProto("target", new(autowiredComponent))
+	cc.WrapAndAddProto("a", &recordingComponent{Name: "a", Events: &events})
+	cc.WrapAndAddProto("b", &recordingComponent{Name: "b", Events: &events})
+
+	if err := cc.Populate(); err == nil {
+		t.Errorf("Expected an error when more than one component could be autowired")
+	}
+
+}
+
+func TestAutowiringRecomputedOnPopulate(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+
+	target := new(autowiredComponent)
+	collector := new(collectingComponent)
+	cc.WrapAndAddProto("target", target)
+	cc.WrapAndAddProto("collector", collector)
+	cc.WrapAndAddProto("a", &recordingComponent{Name: "a", Events: &events})
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	if len(cc.protoComponents["target"].Dependencies) != 0 || len(cc.protoComponents["collector"].CollectionDependencies) != 0 {
+		t.Errorf("Expected autowiring not to modify the protos")
+	}
+
+	cc.WrapAndAddProto("b", &recordingComponent{Name: "b", Events: &events})
+	cc.protoComponents["target"].AddDependency("Starter", "b")
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	if target.Starter != cc.AllComponents()["b"].Instance {
+		t.Errorf("Expected explicit wiring to be used")
+	}
+
+	if len(collector.StartableList) != 2 {
+		t.Errorf("Expected a component added before Populate to be autowired into collections, got %v", collector.StartableList)
+	}
+
+	delete(cc.protoComponents["target"].Dependencies, "Starter")
+
+	if err := cc.Populate(); err == nil {
+		t.Errorf("Expected an ambiguous autowire to be reported after a second candidate was added")
+	}
+
+}
+
+type collectingComponent struct {
+	StartableList []Startable          `quilt:"inject"`
+	StartableMap  map[string]Startable `quilt:"inject"`
+}
+
+func TestInjectCollections(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+
+	target := new(collectingComponent)
+	cc.WrapAndAddProto("target", target)
+	cc.WrapAndAddProto("b", &recordingComponent{Name: "b", Events: &events})
+	cc.WrapAndAddProto("a", &recordingComponent{Name: "a", Events: &events})
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	if len(target.StartableList) != 2 || target.StartableList[0] != cc.AllComponents()["a"].Instance {
+		t.Errorf("Expected components a and b to be injected in name order, got %v", target.StartableList)
+	}
+
+	if len(target.StartableMap) != 2 || target.StartableMap["b"] != cc.AllComponents()["b"].Instance {
+		t.Errorf("Expected components a and b to be injected keyed by name, got %v", target.StartableMap)
+	}
+
+}
+
+func TestAllValidationProblemsReported(t *testing.T) {
+
+	cc := newTestContainer()
+
+	service := CreateProtoComponent(new(orderComponent), "orderService")
+	service.AddDependency("Repo", "missingRepo")
+	service.AddDependency("Missing", "orderRepo")
+	service.AddConfigPromise("Cache", "Missing.Path")
+
+	repo := CreateProtoComponent(new(orderComponent), "orderRepo")
+	repo.AddDependency("Cache", "unrelated")
+
+	cc.AddProtos([]*ProtoComponent{service, repo})
+	cc.WrapAndAddProto("unrelated", new(autowiredComponent))
+
+	err := cc.Populate()
+
+	ve, found := err.(*ValidationErrors)
+
+	if !found {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+
+	if len(ve.Problems) != 5 {
+		t.Errorf("Expected five problems to be reported, got %s", ve)
+	}
+
+}
+
+type failingComponent struct {
+	Values map[string][]string
+}
+
+func (fc *failingComponent) StartComponent() error {
+	return errors.New("start failed")
+}
+
+type panickingDecorator struct{}
+
+func (pd *panickingDecorator) OfInterest(component *Component) bool {
+	return true
+}
+
+func (pd *panickingDecorator) DecorateComponent(component *Component, container *ComponentContainer) {
+	panic("decoration failed")
+}
+
+func TestFailuresReturnErrorsWithoutExiting(t *testing.T) {
+
+	cc := newTestContainer()
+	cc.WrapAndAddProto("decorator", new(panickingDecorator))
+
+	if err := cc.Populate(); err == nil {
+		t.Errorf("Expected an error when a decorator panics")
+	}
+
+	cc = newTestContainer()
+	cc.configAccessor.JsonData["Values"] = map[string]interface{}{"empty": []interface{}{}}
+
+	proto := CreateProtoComponent(new(failingComponent), "failing")
+	proto.AddConfigPromise("Values", "Values")
+	cc.AddProto(proto)
+
+	if _, found := cc.Populate().(*config.ConfigTypeError); !found {
+		t.Errorf("Expected a ConfigTypeError when populating a map with an empty array")
+	}
+
+	cc = newTestContainer()
+	cc.WrapAndAddProto("failing", new(failingComponent))
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	cse, found := cc.StartComponents().(*ComponentStartError)
+
+	if !found || cse.ComponentName != "failing" {
+		t.Errorf("Expected a ComponentStartError for component failing, got %v", cse)
+	}
+
+}
+
+type prototypeConsumer struct {
+	Direct  *orderComponent
+	Factory *ComponentFactory
+}
+
+func TestPrototypeScope(t *testing.T) {
+
+	cc := newTestContainer()
+
+	prototype := CreateProtoComponent(new(orderComponent), "prototype")
+	prototype.AddDependency("Repo", "singleton")
+	prototype.Scope = PrototypeScope
+
+	consumer := new(prototypeConsumer)
+	consumerProto := CreateProtoComponent(consumer, "consumer")
+	consumerProto.AddDependency("Direct", "prototype")
+	consumerProto.AddDependency("Factory", "prototype")
+
+	cc.AddProtos([]*ProtoComponent{prototype, consumerProto})
+	cc.WrapAndAddProto("singleton", new(orderComponent))
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	if _, found := cc.AllComponents()["prototype"]; found {
+		t.Errorf("Did not expect a prototype to be registered as a singleton")
+	}
+
+	created, err := consumer.Factory.Create()
+
+	if err != nil {
+		t.Fatalf("Unexpected error creating instance: %s", err)
+	}
+
+	instance := created.(*orderComponent)
+	singleton := cc.AllComponents()["singleton"].Instance
+
+	if instance == consumer.Direct || instance == prototype.Component.Instance {
+		t.Errorf("Expected a new instance to be created")
+	}
+
+	if instance.Repo != singleton || consumer.Direct.Repo != singleton {
+		t.Errorf("Expected new instances to be injected with their dependencies")
+	}
+
+}
+
+type propertyComponent struct {
+	Label  string
+	Limits map[string]int
+	Tags   []string
+	mutex  sync.Mutex
+	count  int
+}
+
+func TestPrototypeInstancesDoNotShareState(t *testing.T) {
+
+	cc := newTestContainer()
+
+	template := new(propertyComponent)
+	template.count = 5
+
+	proto := CreateProtoComponent(template, "prototype")
+	proto.AddProperty("Label", "proto")
+	proto.AddProperty("Limits", map[string]int{"max": 10})
+	proto.AddProperty("Tags", []string{"a", "b"})
+	proto.Scope = PrototypeScope
+
+	cc.AddProto(proto)
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	template.mutex.Lock()
+	defer template.mutex.Unlock()
+
+	created, err := cc.NewInstance("prototype")
+
+	if err != nil {
+		t.Fatalf("Unexpected error creating instance: %s", err)
+	}
+
+	first := created.(*propertyComponent)
+	created, _ = cc.NewInstance("prototype")
+	second := created.(*propertyComponent)
+
+	if first.Label != "proto" || first.Limits["max"] != 10 || len(first.Tags) != 2 {
+		t.Errorf("Expected declared properties to be applied, got %+v", first)
+	}
+
+	if first.count != 0 {
+		t.Errorf("Expected instances to start from a zero value rather than a copy of the template")
+	}
+
+	first.Limits["max"] = 20
+	first.Tags[0] = "z"
+
+	if second.Limits["max"] != 10 || second.Tags[0] != "a" {
+		t.Errorf("Expected instances not to share maps or slices")
+	}
+
+	if !first.mutex.TryLock() {
+		t.Errorf("Expected instances not to copy the template's mutex")
+	}
+}
+
+func TestLazyComponentInitialisedOnLookup(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+
+	lazy := CreateProtoComponent(&recordingComponent{Name: "lazy", Events: &events}, "lazy")
+	lazy.AddDependency("Dependency", "eager")
+	lazy.Lazy = true
+
+	required := CreateProtoComponent(&recordingComponent{Name: "required", Events: &events}, "required")
+	required.Lazy = true
+
+	consumer := CreateProtoComponent(&recordingComponent{Name: "consumer", Events: &events}, "consumer")
+	consumer.AddDependency("Dependency", "required")
+
+	cc.AddProtos([]*ProtoComponent{lazy, required, consumer})
+	cc.WrapAndAddProto("eager", &recordingComponent{Name: "eager", Events: &events})
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	cc.StartComponents()
+
+	if len(events) != 3 {
+		t.Errorf("Expected only eager and depended-on components to start, got %v", events)
+	}
+
+	component, err := cc.ComponentByName("lazy")
+
+	if err != nil {
+		t.Fatalf("Unexpected error looking up lazy component: %s", err)
+	}
+
+	rc := component.Instance.(*recordingComponent)
+
+	if rc.Dependency == nil || events[len(events)-1] != "start lazy" {
+		t.Errorf("Expected lazy component to be injected and started on lookup, got %v", events)
+	}
+
+}
+
+type lookupComponent struct {
+	Target    string
+	container *ComponentContainer
+}
+
+func (lc *lookupComponent) Container(container *ComponentContainer) {
+	lc.container = container
+}
+
+func (lc *lookupComponent) StartComponent() error {
+	_, err := lc.container.ComponentByName(lc.Target)
+	return err
+}
+
+func TestLazyComponentLookupsWhileStarting(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+
+	outer := CreateProtoComponent(&lookupComponent{Target: "inner"}, "outer")
+	outer.Lazy = true
+
+	inner := CreateProtoComponent(&recordingComponent{Name: "inner", Events: &events}, "inner")
+	inner.Lazy = true
+
+	invalid := CreateProtoComponent(&hookedComponent{Name: "invalid", Events: &events}, "invalid")
+	invalid.Lazy = true
+
+	cc.AddProtos([]*ProtoComponent{outer, inner, invalid})
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	cc.StartComponents()
+
+	looked := make(chan error, 1)
+
+	go func() {
+		_, err := cc.ComponentByName("outer")
+		looked <- err
+	}()
+
+	select {
+	case err := <-looked:
+		if err != nil {
+			t.Errorf("Unexpected error looking up lazy component: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Lazy component looking up another component while starting deadlocked")
+	}
+
+	if len(events) != 1 || events[0] != "start inner" {
+		t.Errorf("Expected inner component to be started by outer component, got %v", events)
+	}
+
+	for i := 0; i < 2; i++ {
+
+		if _, err := cc.ComponentByName("invalid"); err == nil {
+			t.Errorf("Expected an error initialising an invalid lazy component")
+		}
+	}
+
+	if _, found := cc.AllComponents()["invalid"]; found {
+		t.Errorf("Did not expect a component that failed to initialise to be registered")
+	}
+
+	if len(events) != 3 {
+		t.Errorf("Expected a failed lazy component to be retried on each lookup, got %v", events)
+	}
+
+}
+
+func TestConditionalComponents(t *testing.T) {
+
+	cc := newTestContainer()
+	cc.configAccessor.JsonData["Feature"] = map[string]interface{}{"Mode": "fast", "Port": float64(8080)}
+	cc.ActivateProfiles([]string{"dev", " test "})
+
+	conditions := map[string]ComponentCondition{
+		"pathExists":    ConfigPathExists("Feature.Mode"),
+		"pathMissing":   ConfigPathExists("Feature.Missing"),
+		"valueEquals":   ConfigValueEquals("Feature.Port", 8080),
+		"valueDiffers":  ConfigValueEquals("Feature.Mode", "slow"),
+		"profileActive": ProfileActive("test"),
+		"profileOff":    ProfileActive("prod"),
+		"envSet":        EnvironmentVariableSet("QUILT_TEST_CONDITION_SET"),
+		"envUnset":      EnvironmentVariableSet("QUILT_TEST_CONDITION_UNSET"),
+	}
+
+	t.Setenv("QUILT_TEST_CONDITION_SET", "")
+	os.Unsetenv("QUILT_TEST_CONDITION_UNSET")
+
+	for name, condition := range conditions {
+		proto := CreateProtoComponent(new(orderComponent), name)
+		proto.AddCondition(condition)
+		cc.AddProto(proto)
+	}
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	for _, name := range []string{"pathExists", "valueEquals", "profileActive", "envSet"} {
+		if cc.AllComponents()[name] == nil {
+			t.Errorf("Expected component %s to be registered", name)
+		}
+	}
+
+	if len(cc.AllComponents()) != 4 {
+		t.Errorf("Expected only components with satisfied conditions to be registered, got %v", cc.AllComponents())
+	}
+
+	if reason := cc.ExcludedComponents()["envUnset"]; reason != "environment variable QUILT_TEST_CONDITION_UNSET is set" {
+		t.Errorf("Expected excluded components to be reported with the unmet condition, got %q", reason)
+	}
+
+	if cd := cc.DescribeComponent("pathMissing"); cd == nil || cd.Excluded == "" {
+		t.Errorf("Expected excluded components to be described, got %v", cd)
+	}
+
+	cc.configAccessor.JsonData["Feature"].(map[string]interface{})["Missing"] = true
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error re-populating container: %s", err)
+	}
+
+	if cc.AllComponents()["pathMissing"] == nil {
+		t.Errorf("Expected an excluded component to be registered once its condition is satisfied")
+	}
+
+}
+
+type slowComponent struct {
+	Delay time.Duration
+}
+
+func (sc *slowComponent) StartComponent() error {
+	time.Sleep(sc.Delay)
+	return nil
+}
+
+type lookupStarter struct {
+	Container *ComponentContainer
+	Lookup    string
+}
+
+func (ls *lookupStarter) StartComponent() error {
+
+	if ls.Lookup == "" {
+
+		for i := 0; i < 50; i++ {
+			for range ls.Container.AllComponents() {
+			}
+		}
+
+		return nil
+	}
+
+	_, err := ls.Container.ComponentByName(ls.Lookup)
+
+	return err
+}
+
+func TestLazyLookupsDuringParallelStart(t *testing.T) {
+
+	cc := newTestContainer()
+	cc.configAccessor.JsonData["ComponentContainer"] = map[string]interface{}{"ParallelStart": true}
+
+	cc.WrapAndAddProto("iterator", &lookupStarter{Container: cc})
+
+	for i := 0; i < 20; i++ {
+
+		lazy := CreateProtoComponent(new(orderComponent), fmt.Sprintf("lazy%d", i))
+		lazy.Lazy = true
+		cc.AddProto(lazy)
+
+		cc.WrapAndAddProto(fmt.Sprintf("lookup%d", i), &lookupStarter{cc, lazy.Component.Name})
+	}
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	if err := cc.StartComponents(); err != nil {
+		t.Fatalf("Unexpected error starting container: %s", err)
+	}
+
+	if len(cc.AllComponents()) != 41 {
+		t.Errorf("Expected all lazy components to be initialised, got %d components", len(cc.AllComponents()))
+	}
+}
+
+func TestParallelStartWithTimeouts(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+	cc.configAccessor.JsonData["ComponentContainer"] = map[string]interface{}{
+		"ParallelStart":          true,
+		"ComponentStartTimeouts": map[string]interface{}{"slow": "10ms"},
+	}
+
+	cc.WrapAndAddProto("a", &recordingComponent{Name: "a", Events: &events})
+	cc.WrapAndAddProto("b", &recordingComponent{Name: "b", Events: &events})
+	cc.WrapAndAddProto("slow", &slowComponent{time.Second})
+
+	cc.protoComponents["a"].AddDependency("Dependency", "b")
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	cse, found := cc.StartComponents().(*ComponentStartError)
+
+	if !found || cse.ComponentName != "slow" {
+		t.Errorf("Expected component slow to time out, got %v", cse)
+	}
+
+	if len(events) != 2 || events[0] != "start b" {
+		t.Errorf("Expected b to start before a, got %v", events)
+	}
+
+	if len(cc.StartupReport().Components) != 3 {
+		t.Errorf("Expected start times for three components, got %d", len(cc.StartupReport().Components))
+	}
+
+	for _, cst := range cc.StartupReport().Components {
+		if cst.Abandoned != (cst.Name == "slow") {
+			t.Errorf("Expected only the timed out component to be marked as abandoned, got %+v", cst)
+		}
+	}
+
+	if cc.Settings().ComponentStartTimeouts["slow"] != 10*time.Millisecond {
+		t.Errorf("Expected per-component start timeouts to be parsed as durations, got %v", cc.Settings().ComponentStartTimeouts)
+	}
+
+}
+
+type reluctantComponent struct {
+	StopDelay time.Duration
+	Busy      bool
+}
+
+func (rc *reluctantComponent) PrepareToStop() {
+}
+
+func (rc *reluctantComponent) ReadyToStop() (bool, error) {
+
+	if rc.Busy {
+		return false, errors.New("busy")
+	}
+
+	return true, nil
+}
+
+func (rc *reluctantComponent) Stop() error {
+	time.Sleep(rc.StopDelay)
+	return nil
+}
+
+func TestShutdownReport(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+	cc.configAccessor.JsonData["ComponentContainer"] = map[string]interface{}{
+		"ShutdownTimeout":       "1s",
+		"ComponentStopTimeouts": map[string]interface{}{"reluctant": "10ms"},
+		"ReadyToStopInterval":   "5ms",
+	}
+
+	cc.WrapAndAddProto("reluctant", &reluctantComponent{StopDelay: time.Second})
+	cc.WrapAndAddProto("a", &recordingComponent{Name: "a", Events: &events})
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	report := cc.ShutdownComponents(context.Background())
+
+	if report.Clean() {
+		t.Errorf("Did not expect shutdown to be clean")
+	}
+
+	errored := report.Errored()
+
+	if len(errored) != 1 || errored[0].Name != "reluctant" {
+		t.Errorf("Expected reluctant to be reported as failing to stop in time, got %v", errored)
+	}
+
+	if len(report.Components) != 2 || events[0] != "stop a" {
+		t.Errorf("Expected both components to be stopped, got %v", report.Components)
+	}
+
+	if report.Elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the per-component stop timeout to apply, shutdown took %s", report.Elapsed)
+	}
+
+}
+
+func TestShutdownDeadlineSkipsStop(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+	cc.configAccessor.JsonData["ComponentContainer"] = map[string]interface{}{
+		"ShutdownTimeout":      "50ms",
+		"ComponentStopTimeout": "10ms",
+		"ReadyToStopInterval":  "5ms",
+	}
+
+	cc.WrapAndAddProto("reluctant", &reluctantComponent{Busy: true})
+	cc.WrapAndAddProto("a", &recordingComponent{Name: "a", Events: &events})
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	report := cc.ShutdownComponents(context.Background())
+
+	if len(report.NotReady) != 1 || report.NotReady[0] != "reluctant" {
+		t.Errorf("Expected reluctant to be reported as not ready, got %v", report.NotReady)
+	}
+
+	if len(report.Errored()) != 2 || len(events) != 0 {
+		t.Errorf("Expected components not to be stopped after the shutdown deadline, got %v", events)
+	}
+
+}
+
+func TestReadyToStopBoundedWithoutDeadline(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+	cc.configAccessor.JsonData["ComponentContainer"] = map[string]interface{}{
+		"ShutdownTimeout":     "0s",
+		"ReadyToStopInterval": "1ms",
+	}
+
+	cc.WrapAndAddProto("reluctant", &reluctantComponent{Busy: true})
+	cc.WrapAndAddProto("a", &recordingComponent{Name: "a", Events: &events})
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	report := cc.ShutdownComponents(context.Background())
+
+	if len(report.NotReady) != 1 || report.NotReady[0] != "reluctant" {
+		t.Errorf("Expected reluctant to be reported as not ready, got %v", report.NotReady)
+	}
+
+	if len(events) != 1 || events[0] != "stop a" {
+		t.Errorf("Expected components to be stopped after giving up waiting, got %v", events)
+	}
+
+}
+
+type accessorComponent struct {
+	Dependency *recordingComponent
+	container  *ComponentContainer
+}
+
+func (ac *accessorComponent) Container(container *ComponentContainer) {
+	ac.container = container
+}
+
+func TestDescribe(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+
+	accessor := CreateProtoComponent(new(accessorComponent), "accessor")
+	accessor.AddDependency("Dependency", "recorder")
+
+	cc.AddProto(accessor)
+	cc.WrapAndAddProto("recorder", &recordingComponent{Name: "recorder", Events: &events})
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	descriptions := cc.Describe()
+
+	if len(descriptions) != 2 || descriptions[0].Name != "accessor" {
+		t.Fatalf("Expected descriptions of two components in name order, got %v", descriptions)
+	}
+
+	ad := descriptions[0]
+
+	if ad.Type != "*ioc.accessorComponent" || ad.Dependencies["Dependency"] != "recorder" {
+		t.Errorf("Unexpected type or dependencies in description %v", ad)
+	}
+
+	if len(ad.Decorators) != 1 || ad.Decorators[0] != containerDecoratorComponentName {
+		t.Errorf("Expected accessor to be decorated by the container decorator, got %v", ad.Decorators)
+	}
+
+	lifecycle := cc.DescribeComponent("recorder").Lifecycle
+
+	if len(lifecycle) != 2 || lifecycle[0] != "Startable" || lifecycle[1] != "Stoppable" {
+		t.Errorf("Expected recorder to be Startable and Stoppable, got %v", lifecycle)
+	}
+
+}
+
+func TestDependencyGraphExport(t *testing.T) {
+
+	cc := newTestContainer()
+
+	service := CreateProtoComponent(new(orderComponent), "orderService")
+	service.AddDependency("Repo", FrameworkPrefix+"Repo")
+	service.AddConfigPromise("Cache", "Order.Cache")
+
+	cc.AddProto(service)
+	cc.WrapAndAddProto(FrameworkPrefix+"Repo", new(orderComponent))
+
+	var dot bytes.Buffer
+	cc.DependencyGraph().WriteDot(&dot)
+
+	expectedEdge := `"component:orderService" -> "component:quiltRepo" [label="Repo"];`
+
+	if !strings.Contains(dot.String(), expectedEdge) {
+		t.Errorf("Expected DOT output to contain %s, got\n%s", expectedEdge, dot.String())
+	}
+
+	var js bytes.Buffer
+	cc.DependencyGraph().WriteJson(&js)
+
+	graph := new(DependencyGraph)
+
+	if err := json.Unmarshal(js.Bytes(), graph); err != nil {
+		t.Fatalf("Unable to parse JSON graph: %s", err)
+	}
+
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 2 {
+		t.Errorf("Expected three nodes and two edges, got %d and %d", len(graph.Nodes), len(graph.Edges))
+	}
+
+	if graph.Nodes[0].Name != "orderService" || !graph.Nodes[1].Framework {
+		t.Errorf("Expected nodes sorted by name with framework components flagged, got %v", graph.Nodes)
+	}
+
+}
+
+type orderedDecorator struct {
+	Priority int
+	After    []string
+	Before   []string
+	Applied  *[]string
+	name     string
+}
+
+func (od *orderedDecorator) OfInterest(component *Component) bool {
+	return component.Name == "target"
+}
+
+func (od *orderedDecorator) DecorateComponent(component *Component, container *ComponentContainer) {
+	*od.Applied = append(*od.Applied, od.name)
+}
+
+func (od *orderedDecorator) DecoratorPriority() int {
+	return od.Priority
+}
+
+func (od *orderedDecorator) DecorateAfter() []string {
+	return od.After
+}
+
+func (od *orderedDecorator) DecorateBefore() []string {
+	return od.Before
+}
+
+func TestDecoratorOrdering(t *testing.T) {
+
+	applied := []string{}
+	cc := newTestContainer()
+
+	cc.WrapAndAddProto("target", new(orderComponent))
+	cc.WrapAndAddProto("minusTen", &orderedDecorator{Priority: -10, Applied: &applied, name: "minusTen"})
+	cc.WrapAndAddProto("plusTen", &orderedDecorator{Priority: 10, Applied: &applied, name: "plusTen"})
+	cc.WrapAndAddProto("plusTwenty", &orderedDecorator{Priority: 20, Applied: &applied, name: "plusTwenty"})
+	cc.WrapAndAddProto("zero", &orderedDecorator{Applied: &applied, name: "zero"})
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	expected := "minusTen zero plusTen plusTwenty"
+
+	if strings.Join(applied, " ") != expected {
+		t.Errorf("Expected decorators with lower priority values to be applied first (%s), got %v", expected, applied)
+	}
+
+	applied = []string{}
+	cc = newTestContainer()
+
+	cc.WrapAndAddProto("target", new(orderComponent))
+	cc.WrapAndAddProto("minusTen", &orderedDecorator{Priority: -10, Applied: &applied, name: "minusTen"})
+	cc.WrapAndAddProto("plusTen", &orderedDecorator{Priority: 10, Applied: &applied, name: "plusTen"})
+	cc.WrapAndAddProto("beforeMinusTen", &orderedDecorator{Priority: 20, Before: []string{"minusTen"}, Applied: &applied, name: "beforeMinusTen"})
+	cc.WrapAndAddProto("afterPlusTen", &orderedDecorator{After: []string{"plusTen", "missing"}, Applied: &applied, name: "afterPlusTen"})
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	expected = "plusTen afterPlusTen beforeMinusTen minusTen"
+
+	if strings.Join(applied, " ") != expected {
+		t.Errorf("Expected decorators to be applied in order %s, got %v", expected, applied)
+	}
+
+	cc = newTestContainer()
+	cc.WrapAndAddProto("a", &orderedDecorator{After: []string{"b"}, Applied: &applied, name: "a"})
+	cc.WrapAndAddProto("b", &orderedDecorator{After: []string{"a"}, Applied: &applied, name: "b"})
+
+	if err := cc.Populate(); err == nil {
+		t.Errorf("Expected an error when decorator constraints conflict")
+	}
+
+}
+
+type hookedComponent struct {
+	Name       string
+	Valid      bool
+	Dependency *hookedComponent
+	Events     *[]string
+}
+
+func (hc *hookedComponent) ValidateComponent() error {
+	*hc.Events = append(*hc.Events, "validate "+hc.Name)
+
+	if !hc.Valid {
+		return errors.New("not valid")
+	}
+
+	return nil
+}
+
+func (hc *hookedComponent) CleanupComponent() error {
+	*hc.Events = append(*hc.Events, "cleanup "+hc.Name)
+	return nil
+}
+
+func TestValidationAndCleanupHooks(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+
+	cc.WrapAndAddProto("a", &hookedComponent{Name: "a", Valid: true, Events: &events})
+	cc.WrapAndAddProto("b", &hookedComponent{Name: "b", Valid: true, Events: &events})
+	cc.protoComponents["a"].AddDependency("Dependency", "b")
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	cc.ShutdownComponents(context.Background())
+
+	expected := "validate b,validate a,cleanup a,cleanup b"
+
+	if strings.Join(events, ",") != expected {
+		t.Errorf("Expected hooks to be called in order %s, got %v", expected, events)
+	}
+
+	cc = newTestContainer()
+	cc.WrapAndAddProto("invalid", &hookedComponent{Name: "invalid", Events: &events})
+
+	ve, found := cc.Populate().(*ValidationErrors)
+
+	if !found || len(ve.Problems) != 1 {
+		t.Fatalf("Expected a validation error, got %v", ve)
+	}
+
+	if cve, found := ve.Problems[0].(*ComponentValidationError); !found || cve.ComponentName != "invalid" {
+		t.Errorf("Expected a ComponentValidationError for invalid, got %v", ve.Problems[0])
+	}
+
+	cc = newTestContainer()
+
+	prototype := CreateProtoComponent(new(hookedComponent), "prototype")
+	prototype.AddProperty("Name", "prototype")
+	prototype.AddProperty("Events", &events)
+	prototype.Scope = PrototypeScope
+
+	cc.AddProto(prototype)
+	cc.WrapAndAddProto("consumer", &hookedComponent{Name: "consumer", Valid: true, Events: &events})
+	cc.protoComponents["consumer"].AddDependency("Dependency", "prototype")
+
+	ve, found = cc.Populate().(*ValidationErrors)
+
+	if !found || len(ve.Problems) != 1 {
+		t.Fatalf("Expected a validation error for the injected prototype instance, got %v", ve)
+	}
+
+	if cve, found := ve.Problems[0].(*ComponentValidationError); !found || cve.ComponentName != "prototype" {
+		t.Errorf("Expected a ComponentValidationError for prototype, got %v", ve.Problems[0])
+	}
+
+}
+
+type panickingCleanup struct{}
+
+func (pc *panickingCleanup) CleanupComponent() error {
+	panic("cleanup failed")
+}
+
+func TestCleanupPanicRecovered(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+
+	cc.WrapAndAddProto("a", &hookedComponent{Name: "a", Valid: true, Events: &events})
+	cc.WrapAndAddProto("panicking", new(panickingCleanup))
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	report := cc.ShutdownComponents(context.Background())
+
+	if len(report.Cleanup) != 2 || events[len(events)-1] != "cleanup a" {
+		t.Errorf("Expected cleanup to continue after a panic, got %v", events)
+	}
+
+	errored := report.Errored()
+
+	if len(errored) != 1 || errored[0].Name != "panicking" {
+		t.Errorf("Expected the panic to be reported as a cleanup error, got %v", errored)
+	}
+
+}
+
+func TestFluentDefinition(t *testing.T) {
+
+	cc := newTestContainer()
+	cc.configAccessor.JsonData["Order"] = map[string]interface{}{"Name": "orders"}
+
+	err := cc.Register(
+		Define("consumer", new(collectingComponent)).DependsOnAll("StartableList", "recorder"),
+		Define("recorder", &recordingComponent{Events: &[]string{}}).Config("Name", "Order.Name").Condition(ConfigPathExists("Order")),
+	)
+
+	if err != nil {
+		t.Fatalf("Unexpected error registering definitions: %s", err)
+	}
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	recorder := cc.AllComponents()["recorder"].Instance.(*recordingComponent)
+
+	if recorder.Name != "orders" {
+		t.Errorf("Expected config to be injected, got %s", recorder.Name)
+	}
+
+	_, err = Define("typo", new(orderComponent)).DependsOn("Rep", "orderRepo").Config("Cache", "Order.Cache").Proto()
+
+	ve, found := err.(*ValidationErrors)
+
+	if !found || len(ve.Problems) != 2 {
+		t.Errorf("Expected an unknown field and an unsupported config type to be reported, got %v", err)
+	}
+
+}
+
+type childConsumer struct {
+	Shared   *recordingComponent
+	Replaced *recordingComponent
+	Lazy     *recordingComponent
+	PerUse   *recordingComponent
+}
+
+func TestChildContainer(t *testing.T) {
+
+	events := []string{}
+	parent := newTestContainer()
+	parent.WrapAndAddProto("shared", &recordingComponent{Name: "shared", Events: &events})
+	parent.WrapAndAddProto("replaced", &recordingComponent{Name: "parent", Events: &events})
+
+	lazy := CreateProtoComponent(&recordingComponent{Name: "lazy", Events: &events}, "lazy")
+	lazy.Lazy = true
+
+	perUse := CreateProtoComponent(new(recordingComponent), "perUse")
+	perUse.AddProperty("Name", "perUse")
+	perUse.Scope = PrototypeScope
+
+	parent.AddProtos([]*ProtoComponent{lazy, perUse})
+
+	if err := parent.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating parent: %s", err)
+	}
+
+	parentLookup := make(chan error, 1)
+
+	go func() {
+		_, err := parent.ComponentByName("lazy")
+		parentLookup <- err
+	}()
+
+	child := parent.NewChild()
+	child.WrapAndAddProto("replaced", &recordingComponent{Name: "child", Events: &events})
+
+	consumer := new(childConsumer)
+	proto := CreateProtoComponent(consumer, "consumer")
+	proto.AddDependency("Shared", "shared")
+	proto.AddDependency("Replaced", "replaced")
+	proto.AddDependency("Lazy", "lazy")
+	proto.AddDependency("PerUse", "perUse")
+	child.AddProto(proto)
+
+	if err := child.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating child: %s", err)
+	}
+
+	if err := <-parentLookup; err != nil {
+		t.Fatalf("Unexpected error looking up lazy parent component: %s", err)
+	}
+
+	if consumer.Lazy != parent.AllComponents()["lazy"].Instance {
+		t.Errorf("Expected the parent's lazy component to be initialised and injected")
+	}
+
+	if consumer.PerUse == nil || consumer.PerUse.Name != "perUse" {
+		t.Errorf("Expected a new instance of the parent's prototype to be injected, got %v", consumer.PerUse)
+	}
+
+	if consumer.Shared != parent.AllComponents()["shared"].Instance {
+		t.Errorf("Expected the parent's component to be injected")
+	}
+
+	if consumer.Replaced.Name != "child" {
+		t.Errorf("Expected the child's override to be injected")
+	}
+
+	if found := child.FindByType("*ioc.recordingComponent"); len(found) != 3 {
+		t.Errorf("Expected overridden parent components to be hidden, found %d", len(found))
+	}
+
+	if _, found := child.AllComponents()["shared"]; found {
+		t.Errorf("Expected the child to only manage its own components")
+	}
+
+}
+
+type reloadListener struct {
+	Reloaded []string
+}
+
+func (rl *reloadListener) ComponentsReloaded(names []string) error {
+	rl.Reloaded = names
+	return nil
+}
+
+func TestReloadRebuildsAffectedComponents(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+	cc.configAccessor.JsonData["Store"] = map[string]interface{}{"Name": "store"}
+
+	store := CreateProtoComponent(&recordingComponent{Events: &events}, "store")
+	store.AddConfigPromise("Name", "Store.Name")
+	store.AddProperty("Events", &events)
+	cc.AddProto(store)
+
+	service := CreateProtoComponent(&recordingComponent{Name: "service", Events: &events}, "service")
+	service.AddDependency("Dependency", "store")
+	service.AddProperty("Name", "service")
+	service.AddProperty("Events", &events)
+	cc.AddProto(service)
+
+	cc.WrapAndAddProto("other", &recordingComponent{Name: "other", Events: &events})
+
+	listener := new(reloadListener)
+	cc.WrapAndAddProto("listener", listener)
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	if err := cc.StartComponents(); err != nil {
+		t.Fatalf("Unexpected error starting container: %s", err)
+	}
+
+	original := cc.AllComponents()["service"].Instance
+	events = events[:0]
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal+1, nil)
+	reloaded := &config.ConfigAccessor{JsonData: map[string]interface{}{"Store": map[string]interface{}{"Name": "new-store"}}, FrameworkLogger: lm.CreateLogger("config")}
+
+	report, err := cc.Reload(reloaded)
+
+	if err != nil {
+		t.Fatalf("Unexpected error reloading: %s", err)
+	}
+
+	if !reflect.DeepEqual(report.Rebuilt, []string{"store", "service"}) {
+		t.Errorf("Expected store and its dependent to be rebuilt, got %v", report.Rebuilt)
+	}
+
+	expected := []string{"stop service", "stop store", "start new-store", "start service"}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected %v, got %v", expected, events)
+	}
+
+	rebuilt := cc.AllComponents()["service"].Instance.(*recordingComponent)
+
+	if rebuilt == original || rebuilt.Dependency.Name != "new-store" {
+		t.Errorf("Expected the dependent to be rebuilt with the new dependency")
+	}
+
+	if !reflect.DeepEqual(listener.Reloaded, report.Rebuilt) {
+		t.Errorf("Expected the listener to be notified of the reload")
+	}
+
+}
+
+type serverComponent struct {
+	Host      string
+	Limits    map[string]string
+	Started   int
+	Fail      bool
+	Container *ComponentContainer
+	Lookup    string
+	Events    *[]string
+}
+
+func (sc *serverComponent) StartComponent() error {
+
+	if sc.Lookup != "" {
+
+		if _, err := sc.Container.ComponentByName(sc.Lookup); err != nil {
+			return err
+		}
+	}
+
+	if sc.Fail {
+		return errors.New("unable to start")
+	}
+
+	sc.Started++
+	*sc.Events = append(*sc.Events, "start "+sc.Host)
+
+	return nil
+}
+
+func (sc *serverComponent) PrepareToStop() {
+}
+
+func (sc *serverComponent) ReadyToStop() (bool, error) {
+	return true, nil
+}
+
+func (sc *serverComponent) Stop() error {
+	*sc.Events = append(*sc.Events, "stop "+sc.Host)
+	return nil
+}
+
+func reloadConfig(data map[string]interface{}) *config.ConfigAccessor {
+	lm := logging.CreateComponentLoggerManager(logging.Fatal+1, nil)
+
+	return &config.ConfigAccessor{JsonData: data, FrameworkLogger: lm.CreateLogger("config")}
+}
+
+func serverConfig(host string, fail bool) map[string]interface{} {
+	return map[string]interface{}{"Server": map[string]interface{}{"Host": host, "Fail": fail, "Limits": map[string]interface{}{"Max": "10"}}}
+}
+
+func startServerContainer(t *testing.T, events *[]string) *ComponentContainer {
+
+	cc := newTestContainer()
+	cc.configAccessor = reloadConfig(serverConfig("alpha", false))
+
+	instance := new(serverComponent)
+	instance.Events = events
+	cc.configAccessor.Populate("Server", instance)
+
+	server := CreateProtoComponent(instance, "server")
+	server.AddConfigRoot("Server")
+	server.AddProperty("Events", events)
+	cc.AddProto(server)
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	if err := cc.StartComponents(); err != nil {
+		t.Fatalf("Unexpected error starting container: %s", err)
+	}
+
+	return cc
+}
+
+func TestReloadRebuildsComponentsPopulatedFromConfigRoots(t *testing.T) {
+
+	events := []string{}
+	cc := startServerContainer(t, &events)
+
+	original := cc.AllComponents()["server"].Instance.(*serverComponent)
+	events = events[:0]
+
+	report, err := cc.Reload(reloadConfig(serverConfig("beta", false)))
+
+	if err != nil {
+		t.Fatalf("Unexpected error reloading: %s", err)
+	}
+
+	if !reflect.DeepEqual(report.Changed, []string{"server"}) {
+		t.Errorf("Expected a change beneath the config root to be detected, got %v", report.Changed)
+	}
+
+	rebuilt := cc.AllComponents()["server"].Instance.(*serverComponent)
+
+	if rebuilt == original || rebuilt.Host != "beta" || original.Host != "alpha" {
+		t.Errorf("Expected a new instance populated from the new configuration")
+	}
+
+	if rebuilt.Started != 1 {
+		t.Errorf("Expected the new instance to be built from a zero value rather than copied from the old instance (started %d times)", rebuilt.Started)
+	}
+
+	rebuilt.Limits["Max"] = "20"
+
+	if original.Limits["Max"] != "10" {
+		t.Errorf("Expected the new instance not to share maps with the old instance")
+	}
+
+	expected := []string{"stop alpha", "start beta"}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected %v, got %v", expected, events)
+	}
+}
+
+func TestReloadUsesBuilder(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+	cc.configAccessor = reloadConfig(serverConfig("alpha", false))
+
+	server := CreateProtoComponent(&serverComponent{Host: "alpha", Events: &events}, "server")
+	server.AddConfigRoot("Server")
+	server.Builder = func(ca *config.ConfigAccessor) (interface{}, error) {
+		host, err := ca.String("Server.Host")
+
+		return &serverComponent{Host: "built-" + host, Events: &events}, err
+	}
+	cc.AddProto(server)
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	if _, err := cc.Reload(reloadConfig(serverConfig("beta", false))); err != nil {
+		t.Fatalf("Unexpected error reloading: %s", err)
+	}
+
+	if host := cc.AllComponents()["server"].Instance.(*serverComponent).Host; host != "built-beta" {
+		t.Errorf("Expected the component to be rebuilt by its Builder, got %s", host)
+	}
+}
+
+func TestReloadRestoresPreviousComponentsWhenStartFails(t *testing.T) {
+
+	events := []string{}
+	cc := startServerContainer(t, &events)
+
+	original := cc.AllComponents()["server"].Instance
+	previous := cc.configAccessor
+	events = events[:0]
+
+	if _, err := cc.Reload(reloadConfig(serverConfig("beta", true))); err == nil {
+		t.Fatalf("Expected an error when a rebuilt component cannot start")
+	}
+
+	if cc.AllComponents()["server"].Instance != original || cc.configAccessor != previous {
+		t.Errorf("Expected the previous instance and configuration to be restored")
+	}
+
+	expected := []string{"stop alpha", "start alpha"}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected %v, got %v", expected, events)
+	}
+}
+
+func TestReloadAllowsLookupsDuringStart(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+	cc.configAccessor = reloadConfig(serverConfig("alpha", false))
+
+	server := CreateProtoComponent(&serverComponent{Host: "alpha", Lookup: "other", Events: &events}, "server")
+	server.AddConfigRoot("Server")
+	server.AddProperty("Container", cc)
+	server.AddProperty("Lookup", "other")
+	server.AddProperty("Events", &events)
+	cc.AddProto(server)
+
+	cc.WrapAndAddProto("other", &recordingComponent{Name: "other", Events: &events})
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	if err := cc.StartComponents(); err != nil {
+		t.Fatalf("Unexpected error starting container: %s", err)
+	}
+
+	done := make(chan error)
+
+	go func() {
+		_, err := cc.Reload(reloadConfig(serverConfig("beta", false)))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error reloading: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected a component to be able to look up components while being started after a reload")
+	}
+}
+
+func TestReloadRejectsChangedConditions(t *testing.T) {
+
+	events := []string{}
+	cc := newTestContainer()
+	cc.configAccessor = reloadConfig(map[string]interface{}{"Feature": true})
+
+	feature := CreateProtoComponent(&recordingComponent{Name: "feature", Events: &events}, "feature")
+	feature.AddCondition(ConfigPathExists("Feature"))
+	cc.AddProto(feature)
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	if err := cc.StartComponents(); err != nil {
+		t.Fatalf("Unexpected error starting container: %s", err)
+	}
+
+	events = events[:0]
+
+	if _, err := cc.Reload(reloadConfig(map[string]interface{}{})); err == nil {
+		t.Errorf("Expected an error when a reload would exclude a component")
+	}
+
+	if len(events) != 0 || cc.AllComponents()["feature"] == nil {
+		t.Errorf("Expected no components to be stopped or removed, got %v", events)
+	}
+}
+
+type healthyComponent struct {
+	State  int
+	Detail string
+	Block  bool
+}
+
+func (hc *healthyComponent) HealthStatus() (int, string) {
+	return hc.State, hc.Detail
+}
+
+func (hc *healthyComponent) BlockAccess() (bool, error) {
+	return hc.Block, nil
+}
+
+func TestHealthAggregation(t *testing.T) {
+
+	cc := newTestContainer()
+
+	db := &healthyComponent{State: HealthOK}
+	cache := &healthyComponent{State: HealthDegraded, Detail: "slow"}
+
+	cc.WrapAndAddProto("db", db)
+	cc.WrapAndAddProto("cache", cache)
+
+	if err := cc.Populate(); err != nil {
+		t.Fatalf("Unexpected error populating container: %s", err)
+	}
+
+	if report := cc.Health(); report.Ready {
+		t.Errorf("Expected the container not to be ready before it has started")
+	}
+
+	if err := cc.StartComponents(); err != nil {
+		t.Fatalf("Unexpected error starting container: %s", err)
+	}
+
+	report := cc.Health()
+
+	if report.State != HealthDegraded || !report.Live || !report.Ready {
+		t.Errorf("Expected a live, ready, degraded container, got %+v", report)
+	}
+
+	db.Block = true
+
+	if report := cc.CheckHealth(); report.Ready {
+		t.Errorf("Expected a blocking component to make the container unready")
+	}
+
+	db.Block = false
+	db.State = HealthFailed
+
+	if report := cc.CheckHealth(); report.Live || report.Status != "FAILED" {
+		t.Errorf("Expected a failed component to make the container unhealthy, got %+v", report)
+	}
+
+	db.State = HealthOK
+
+	shutdown := cc.ShutdownComponents(context.Background())
+
+	if shutdown.Health.Ready || cc.Health().Ready {
+		t.Errorf("Expected the container to report unready once shutdown has begun")
+	}
+
+}
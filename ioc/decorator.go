@@ -4,3 +4,20 @@ type ComponentDecorator interface {
 	OfInterest(component *Component) bool
 	DecorateComponent(component *Component, container *ComponentContainer)
 }
+
+type namedDecorator struct {
+	name      string
+	decorator ComponentDecorator
+}
+
+// PrioritisedDecorator controls the order in which decorators are applied. Decorators with lower
+// priority values are applied first; decorators that do not implement this interface have priority 0.
+// Constraints declared through OrderedDecorator take precedence over priorities.
+type PrioritisedDecorator interface {
+	DecoratorPriority() int
+}
+
+type OrderedDecorator interface {
+	DecorateBefore() []string
+	DecorateAfter() []string
+}
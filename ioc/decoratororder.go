@@ -0,0 +1,120 @@
+package ioc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+func (cc *ComponentContainer) orderDecorators(decorators []*namedDecorator) ([]*namedDecorator, error) {
+
+	byName := make(map[string]*namedDecorator)
+
+	for _, nd := range decorators {
+		byName[nd.name] = nd
+	}
+
+	mustFollow := make(map[string]map[string]bool)
+
+	for _, nd := range decorators {
+		mustFollow[nd.name] = make(map[string]bool)
+	}
+
+	for _, nd := range decorators {
+
+		od, ordered := nd.decorator.(OrderedDecorator)
+
+		if !ordered {
+			continue
+		}
+
+		for _, after := range od.DecorateAfter() {
+			cc.addDecoratorConstraint(mustFollow, byName, after, nd.name)
+		}
+
+		for _, before := range od.DecorateBefore() {
+			cc.addDecoratorConstraint(mustFollow, byName, nd.name, before)
+		}
+	}
+
+	remaining := append([]*namedDecorator{}, decorators...)
+	result := make([]*namedDecorator, 0, len(decorators))
+	applied := make(map[string]bool)
+
+	for len(remaining) > 0 {
+
+		sort.Slice(remaining, func(i, j int) bool {
+			pi, pj := decoratorPriority(remaining[i]), decoratorPriority(remaining[j])
+
+			if pi != pj {
+				return pi < pj
+			}
+
+			return remaining[i].name < remaining[j].name
+		})
+
+		next := -1
+
+		for i, nd := range remaining {
+
+			if allApplied(mustFollow[nd.name], applied) {
+				next = i
+				break
+			}
+		}
+
+		if next < 0 {
+			return nil, conflictingDecoratorsError(remaining)
+		}
+
+		nd := remaining[next]
+		applied[nd.name] = true
+		result = append(result, nd)
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+
+	return result, nil
+}
+
+func (cc *ComponentContainer) addDecoratorConstraint(mustFollow map[string]map[string]bool, byName map[string]*namedDecorator, first, second string) {
+
+	if byName[first] == nil || byName[second] == nil {
+		cc.FrameworkLogger.LogDebugf("Ignoring ordering constraint between decorators %s and %s as one is not registered", first, second)
+		return
+	}
+
+	mustFollow[second][first] = true
+}
+
+func allApplied(required map[string]bool, applied map[string]bool) bool {
+
+	for name := range required {
+		if !applied[name] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func decoratorPriority(nd *namedDecorator) int {
+
+	if pd, prioritised := nd.decorator.(PrioritisedDecorator); prioritised {
+		return pd.DecoratorPriority()
+	}
+
+	return 0
+}
+
+func conflictingDecoratorsError(unordered []*namedDecorator) error {
+
+	names := make([]string, len(unordered))
+
+	for i, nd := range unordered {
+		names[i] = nd.name
+	}
+
+	sort.Strings(names)
+
+	return fmt.Errorf("Conflicting ordering constraints between decorators %s", strings.Join(names, ", "))
+}
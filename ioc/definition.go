@@ -0,0 +1,172 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type ComponentDefinition struct {
+	proto    *ProtoComponent
+	problems []error
+}
+
+func Define(name string, instance interface{}) *ComponentDefinition {
+
+	cd := new(ComponentDefinition)
+	cd.proto = CreateProtoComponent(instance, name)
+
+	if !isStructPointer(instance) {
+		cd.problems = append(cd.problems, fmt.Errorf("Component %s must be a pointer to a struct (was %T)", name, instance))
+	}
+
+	return cd
+}
+
+func (cd *ComponentDefinition) DependsOn(fieldName, componentName string) *ComponentDefinition {
+
+	if _, ok := cd.field(fieldName); ok {
+		cd.proto.AddDependency(fieldName, componentName)
+	}
+
+	return cd
+}
+
+func (cd *ComponentDefinition) DependsOnAll(fieldName string, componentNames ...string) *ComponentDefinition {
+
+	field, ok := cd.field(fieldName)
+
+	if !ok {
+		return cd
+	}
+
+	if !isCollectionType(field.Type()) {
+		cd.fail("%s.%s is of type %s and cannot hold a collection of components", cd.name(), fieldName, field.Type())
+		return cd
+	}
+
+	cd.proto.AddCollectionDependency(fieldName, componentNames)
+
+	return cd
+}
+
+func (cd *ComponentDefinition) Config(fieldName, configPath string) *ComponentDefinition {
+
+	field, ok := cd.field(fieldName)
+
+	if !ok {
+		return cd
+	}
+
+	switch field.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Uint, reflect.Float64, reflect.Slice, reflect.Map:
+		cd.proto.AddConfigPromise(fieldName, configPath)
+	default:
+		cd.fail("%s.%s is of type %s which cannot be populated from configuration", cd.name(), fieldName, field.Type())
+	}
+
+	return cd
+}
+
+func (cd *ComponentDefinition) Property(fieldName string, value interface{}) *ComponentDefinition {
+
+	field, ok := cd.field(fieldName)
+
+	if !ok {
+		return cd
+	}
+
+	if _, err := propertyValue(value, field.Type()); err != nil {
+		cd.fail("Unable to set %s.%s: %s", cd.name(), fieldName, err)
+		return cd
+	}
+
+	cd.proto.AddProperty(fieldName, value)
+
+	return cd
+}
+
+func (cd *ComponentDefinition) Condition(condition ComponentCondition) *ComponentDefinition {
+	cd.proto.AddCondition(condition)
+
+	return cd
+}
+
+func (cd *ComponentDefinition) TolerateCycle(fieldName string) *ComponentDefinition {
+
+	if _, ok := cd.field(fieldName); ok {
+		cd.proto.TolerateCycle(fieldName)
+	}
+
+	return cd
+}
+
+func (cd *ComponentDefinition) Prototype() *ComponentDefinition {
+	cd.proto.Scope = PrototypeScope
+
+	return cd
+}
+
+func (cd *ComponentDefinition) Lazy() *ComponentDefinition {
+	cd.proto.Lazy = true
+
+	return cd
+}
+
+func (cd *ComponentDefinition) Proto() (*ProtoComponent, error) {
+
+	if len(cd.problems) > 0 {
+		return nil, &ValidationErrors{cd.problems}
+	}
+
+	return cd.proto, nil
+}
+
+func (cd *ComponentDefinition) field(fieldName string) (reflect.Value, bool) {
+
+	if !isStructPointer(cd.proto.Component.Instance) {
+		return reflect.Value{}, false
+	}
+
+	field, err := settableField(cd.proto, fieldName)
+
+	if err != nil {
+		cd.problems = append(cd.problems, err)
+		return field, false
+	}
+
+	return field, true
+}
+
+func (cd *ComponentDefinition) name() string {
+	return cd.proto.Component.Name
+}
+
+func (cd *ComponentDefinition) fail(format string, a ...interface{}) {
+	cd.problems = append(cd.problems, fmt.Errorf(format, a...))
+}
+
+func (cc *ComponentContainer) Register(definitions ...*ComponentDefinition) error {
+
+	problems := []error{}
+	protos := make([]*ProtoComponent, 0, len(definitions))
+
+	for _, cd := range definitions {
+
+		proto, err := cd.Proto()
+
+		if err != nil {
+			problems = append(problems, cd.problems...)
+			continue
+		}
+
+		protos = append(protos, proto)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationErrors{problems}
+	}
+
+	cc.AddProtos(protos)
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package ioc
+
+import (
+	"reflect"
+	"sort"
+)
+
+type ComponentDescription struct {
+	Name                   string
+	Type                   string
+	Scope                  int
+	Lazy                   bool
+	Initialised            bool
+	Excluded               string
+	Lifecycle              []string
+	Dependencies           map[string]string
+	CollectionDependencies map[string][]string
+	ConfigPromises         map[string]string
+	Decorators             []string
+}
+
+func (cc *ComponentContainer) Describe() []*ComponentDescription {
+
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	descriptions := make([]*ComponentDescription, 0, len(cc.protoComponents)+len(cc.excluded))
+
+	for _, name := range cc.sortedProtoNames() {
+		descriptions = append(descriptions, cc.describe(cc.protoComponents[name]))
+	}
+
+	for _, name := range cc.sortedExcludedNames() {
+		descriptions = append(descriptions, cc.describeExcluded(cc.excluded[name]))
+	}
+
+	return descriptions
+}
+
+func (cc *ComponentContainer) DescribeComponent(name string) *ComponentDescription {
+
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	if ec := cc.excluded[name]; ec != nil {
+		return cc.describeExcluded(ec)
+	}
+
+	proto := cc.protoComponents[name]
+
+	if proto == nil {
+		return nil
+	}
+
+	return cc.describe(proto)
+}
+
+func (cc *ComponentContainer) ExcludedComponents() map[string]string {
+
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	excluded := make(map[string]string)
+
+	for name, ec := range cc.excluded {
+		excluded[name] = ec.condition.Describe()
+	}
+
+	return excluded
+}
+
+func (cc *ComponentContainer) describeExcluded(ec *excludedComponent) *ComponentDescription {
+
+	cd := cc.describe(ec.proto)
+	cd.Excluded = ec.condition.Describe()
+
+	return cd
+}
+
+func (cc *ComponentContainer) sortedExcludedNames() []string {
+
+	names := make([]string, 0, len(cc.excluded))
+
+	for name := range cc.excluded {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (cc *ComponentContainer) describe(proto *ProtoComponent) *ComponentDescription {
+
+	component := proto.Component
+
+	cd := new(ComponentDescription)
+	cd.Name = component.Name
+	cd.Type = reflect.TypeOf(component.Instance).String()
+	cd.Scope = proto.Scope
+	cd.Lazy = proto.Lazy
+	cd.Initialised = cc.allComponents[component.Name] != nil
+	cd.Lifecycle = LifecycleInterfaces(component.Instance)
+	w := cc.wiringFor(proto)
+
+	cd.Dependencies = copyStringMap(w.dependencies)
+	cd.ConfigPromises = copyStringMap(proto.ConfigPromises)
+	cd.CollectionDependencies = make(map[string][]string)
+
+	for field, members := range w.collections {
+		cd.CollectionDependencies[field] = append([]string{}, members...)
+	}
+
+	cd.Decorators = append([]string{}, cc.decoratedBy[component.Name]...)
+
+	return cd
+}
+
+func LifecycleInterfaces(instance interface{}) []string {
+
+	implemented := []string{}
+
+	if _, found := instance.(Startable); found {
+		implemented = append(implemented, "Startable")
+	}
+
+	if _, found := instance.(Stoppable); found {
+		implemented = append(implemented, "Stoppable")
+	}
+
+	if _, found := instance.(AccessibilityBlocker); found {
+		implemented = append(implemented, "AccessibilityBlocker")
+	}
+
+	if _, found := instance.(Accessible); found {
+		implemented = append(implemented, "Accessible")
+	}
+
+	if _, found := instance.(Validatable); found {
+		implemented = append(implemented, "Validatable")
+	}
+
+	if _, found := instance.(Cleanable); found {
+		implemented = append(implemented, "Cleanable")
+	}
+
+	if _, found := instance.(HealthReporter); found {
+		implemented = append(implemented, "HealthReporter")
+	}
+
+	if _, found := instance.(ReloadListener); found {
+		implemented = append(implemented, "ReloadListener")
+	}
+
+	return implemented
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+
+	c := make(map[string]string)
+
+	for k, v := range m {
+		c[k] = v
+	}
+
+	return c
+}
@@ -0,0 +1,52 @@
+package ioc
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type MissingDependencyError struct {
+	ComponentName  string
+	FieldName      string
+	DependencyName string
+}
+
+func (mde *MissingDependencyError) Error() string {
+	return fmt.Sprintf("No component named %s available (required by %s.%s)", mde.DependencyName, mde.ComponentName, mde.FieldName)
+}
+
+type ComponentStartError struct {
+	ComponentName string
+	Cause         error
+}
+
+func (cse *ComponentStartError) Error() string {
+	return fmt.Sprintf("Unable to start %s: %s", cse.ComponentName, cse.Cause)
+}
+
+type ComponentValidationError struct {
+	ComponentName string
+	Cause         error
+}
+
+func (cve *ComponentValidationError) Error() string {
+	return fmt.Sprintf("%s is not valid: %s", cve.ComponentName, cve.Cause)
+}
+
+type ValidationErrors struct {
+	Problems []error
+}
+
+func (ve *ValidationErrors) Error() string {
+
+	var b bytes.Buffer
+
+	b.WriteString(fmt.Sprintf("%d problem(s) found while populating components:", len(ve.Problems)))
+
+	for _, problem := range ve.Problems {
+		b.WriteString("\n  ")
+		b.WriteString(problem.Error())
+	}
+
+	return b.String()
+}
@@ -0,0 +1,217 @@
+package ioc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	ComponentNode = "component"
+	ConfigNode    = "config"
+
+	DependencyEdge = "dependency"
+	ConfigEdge     = "config"
+)
+
+type DependencyGraph struct {
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*GraphEdge `json:"edges"`
+	index map[string]*GraphNode
+}
+
+type GraphNode struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Type      string `json:"type,omitempty"`
+	Framework bool   `json:"framework"`
+}
+
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Field string `json:"field"`
+	Kind  string `json:"kind"`
+}
+
+func NewDependencyGraph() *DependencyGraph {
+	dg := new(DependencyGraph)
+	dg.index = make(map[string]*GraphNode)
+
+	return dg
+}
+
+func (dg *DependencyGraph) AddComponent(name string, typeName string) {
+	node := dg.node(ComponentNode, name)
+
+	if typeName != "" {
+		node.Type = typeName
+	}
+}
+
+func (dg *DependencyGraph) AddDependency(componentName, fieldName, dependencyName string) {
+	dg.node(ComponentNode, componentName)
+	dg.node(ComponentNode, dependencyName)
+
+	dg.Edges = append(dg.Edges, &GraphEdge{componentName, dependencyName, fieldName, DependencyEdge})
+}
+
+func (dg *DependencyGraph) AddConfigPromise(componentName, fieldName, configPath string) {
+	dg.node(ComponentNode, componentName)
+	dg.node(ConfigNode, configPath)
+
+	dg.Edges = append(dg.Edges, &GraphEdge{componentName, configPath, fieldName, ConfigEdge})
+}
+
+func (dg *DependencyGraph) node(kind, name string) *GraphNode {
+
+	key := kind + ":" + name
+
+	if existing := dg.index[key]; existing != nil {
+		return existing
+	}
+
+	node := &GraphNode{Name: name, Kind: kind}
+	node.Framework = kind == ComponentNode && strings.HasPrefix(name, FrameworkPrefix)
+
+	dg.index[key] = node
+	dg.Nodes = append(dg.Nodes, node)
+
+	return node
+}
+
+func (dg *DependencyGraph) sort() {
+
+	sort.Slice(dg.Nodes, func(i, j int) bool {
+		a, b := dg.Nodes[i], dg.Nodes[j]
+
+		if a.Kind != b.Kind {
+			return a.Kind == ComponentNode
+		}
+
+		return a.Name < b.Name
+	})
+
+	sort.Slice(dg.Edges, func(i, j int) bool {
+		a, b := dg.Edges[i], dg.Edges[j]
+
+		if a.From != b.From {
+			return a.From < b.From
+		}
+
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+
+		return a.To < b.To
+	})
+}
+
+func (dg *DependencyGraph) WriteJson(w io.Writer) error {
+
+	dg.sort()
+
+	encoded, err := json.MarshalIndent(dg, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(encoded)
+
+	return err
+}
+
+func (dg *DependencyGraph) WriteDot(w io.Writer) error {
+
+	dg.sort()
+
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("digraph components {\n")
+	bw.WriteString("\trankdir=LR;\n")
+
+	for _, node := range dg.Nodes {
+		bw.WriteString("\t")
+		bw.WriteString(dotId(node.Kind, node.Name))
+		bw.WriteString(" [")
+		bw.WriteString(dotNodeAttributes(node))
+		bw.WriteString("];\n")
+	}
+
+	for _, edge := range dg.Edges {
+
+		toKind := ComponentNode
+
+		if edge.Kind == ConfigEdge {
+			toKind = ConfigNode
+		}
+
+		bw.WriteString("\t")
+		bw.WriteString(dotId(ComponentNode, edge.From))
+		bw.WriteString(" -> ")
+		bw.WriteString(dotId(toKind, edge.To))
+		bw.WriteString(fmt.Sprintf(" [label=%q", edge.Field))
+
+		if edge.Kind == ConfigEdge {
+			bw.WriteString(", style=dashed")
+		}
+
+		bw.WriteString("];\n")
+	}
+
+	bw.WriteString("}\n")
+
+	return bw.Flush()
+}
+
+func dotId(kind, name string) string {
+	return fmt.Sprintf("%q", kind+":"+name)
+}
+
+func dotNodeAttributes(node *GraphNode) string {
+
+	if node.Kind == ConfigNode {
+		return fmt.Sprintf("label=%q, shape=note", node.Name)
+	}
+
+	label := node.Name
+
+	if node.Type != "" {
+		label = label + "\n" + node.Type
+	}
+
+	attributes := fmt.Sprintf("label=%q, shape=box", label)
+
+	if node.Framework {
+		attributes += ", style=filled, fillcolor=lightgrey"
+	}
+
+	return attributes
+}
+
+func (cc *ComponentContainer) DependencyGraph() *DependencyGraph {
+
+	dg := NewDependencyGraph()
+
+	for _, name := range cc.sortedProtoNames() {
+
+		proto := cc.protoComponents[name]
+
+		dg.AddComponent(name, reflect.TypeOf(proto.Component.Instance).String())
+
+		for _, edge := range cc.dependencyEdges(proto) {
+			dg.AddDependency(name, edge.fieldName, edge.componentName)
+		}
+
+		for field, path := range proto.ConfigPromises {
+			dg.AddConfigPromise(name, field, path)
+		}
+	}
+
+	return dg
+}
@@ -0,0 +1,167 @@
+package ioc
+
+import (
+	"bytes"
+	"sort"
+)
+
+const (
+	unvisited = iota
+	visiting
+	visited
+)
+
+type dependencyLink struct {
+	componentName string
+	fieldName     string
+}
+
+type CircularDependencyError struct {
+	links []dependencyLink
+}
+
+func (cde *CircularDependencyError) Error() string {
+	return "Circular dependency detected: " + cde.Cycle()
+}
+
+func (cde *CircularDependencyError) Cycle() string {
+
+	var b bytes.Buffer
+
+	for _, link := range cde.links {
+		b.WriteString(link.componentName)
+		b.WriteString(".")
+		b.WriteString(link.fieldName)
+		b.WriteString(" -> ")
+	}
+
+	b.WriteString(cde.links[0].componentName)
+
+	return b.String()
+}
+
+func (cc *ComponentContainer) dependencyOrder() ([]*ProtoComponent, error) {
+
+	state := make(map[string]int)
+	ordered := make([]*ProtoComponent, 0, len(cc.protoComponents))
+
+	var err error
+
+	for _, name := range cc.sortedProtoNames() {
+		ordered, err = cc.visitInDependencyOrder(name, state, nil, ordered)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+func (cc *ComponentContainer) visitInDependencyOrder(name string, state map[string]int, path []dependencyLink, ordered []*ProtoComponent) ([]*ProtoComponent, error) {
+
+	proto := cc.protoComponents[name]
+
+	if proto == nil || state[name] == visited {
+		return ordered, nil
+	}
+
+	state[name] = visiting
+
+	var err error
+
+	for _, dep := range cc.dependencyEdges(proto) {
+
+		if proto.ToleratedCycles[dep.fieldName] {
+			continue
+		}
+
+		depName := dep.componentName
+		depPath := append(path[:len(path):len(path)], dependencyLink{name, dep.fieldName})
+
+		if state[depName] == visiting {
+			return nil, newCircularDependencyError(depName, depPath)
+		}
+
+		ordered, err = cc.visitInDependencyOrder(depName, state, depPath, ordered)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	state[name] = visited
+
+	return append(ordered, proto), nil
+}
+
+func newCircularDependencyError(start string, path []dependencyLink) *CircularDependencyError {
+
+	for i, link := range path {
+		if link.componentName == start {
+			return &CircularDependencyError{path[i:]}
+		}
+	}
+
+	return &CircularDependencyError{path}
+}
+
+func (cc *ComponentContainer) sortedProtoNames() []string {
+
+	names := make([]string, 0, len(cc.protoComponents))
+
+	for name := range cc.protoComponents {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (cc *ComponentContainer) dependencyEdges(proto *ProtoComponent) []dependencyLink {
+	return cc.wiringFor(proto).edges()
+}
+
+func (w *wiring) edges() []dependencyLink {
+
+	edges := make([]dependencyLink, 0, len(w.dependencies))
+
+	for _, field := range sortedKeys(w.dependencies) {
+		edges = append(edges, dependencyLink{w.dependencies[field], field})
+	}
+
+	for _, field := range w.collectionFields() {
+		for _, member := range w.collections[field] {
+			edges = append(edges, dependencyLink{member, field})
+		}
+	}
+
+	return edges
+}
+
+func (w *wiring) collectionFields() []string {
+
+	fields := make([]string, 0, len(w.collections))
+
+	for field := range w.collections {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+func sortedKeys(m map[string]string) []string {
+
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,273 @@
+package ioc
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	HealthOK = iota
+	HealthDegraded
+	HealthFailed
+)
+
+type ComponentHealth struct {
+	Name   string
+	State  int
+	Status string
+	Detail string
+}
+
+type HealthReport struct {
+	State      int
+	Status     string
+	Live       bool
+	Ready      bool
+	Components []*ComponentHealth
+	Checked    time.Time
+}
+
+type healthMonitor struct {
+	mutex    sync.Mutex
+	latest   *HealthReport
+	stopping bool
+	stop     chan bool
+}
+
+func HealthStateLabel(state int) string {
+	switch state {
+	case HealthOK:
+		return "OK"
+	case HealthDegraded:
+		return "DEGRADED"
+	case HealthFailed:
+		return "FAILED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (cc *ComponentContainer) Health() *HealthReport {
+
+	cc.health.mutex.Lock()
+	latest := cc.health.latest
+	cc.health.mutex.Unlock()
+
+	if latest != nil {
+		return latest
+	}
+
+	return cc.CheckHealth()
+}
+
+func (cc *ComponentContainer) CheckHealth() *HealthReport {
+
+	cc.mutex.Lock()
+	reporters := cc.snapshotComponents(cc.healthReporters)
+	blockers := cc.snapshotComponents(cc.blocker)
+	accessAllowed := cc.accessAllowed
+	cc.mutex.Unlock()
+
+	byName := make(map[string]*ComponentHealth)
+	blocking := false
+
+	for _, c := range reporters {
+		state, detail := reportHealth(c.Instance.(HealthReporter))
+		recordHealth(byName, c.Name, state, detail)
+	}
+
+	for _, c := range blockers {
+
+		block, err := c.Instance.(AccessibilityBlocker).BlockAccess()
+
+		if !block {
+			continue
+		}
+
+		blocking = true
+		detail := "blocking access"
+
+		if err != nil {
+			detail = fmt.Sprintf("blocking access: %s", err)
+		}
+
+		recordHealth(byName, c.Name, HealthDegraded, detail)
+	}
+
+	report := new(HealthReport)
+	report.Checked = time.Now()
+	report.State = HealthOK
+
+	for _, name := range sortedHealthNames(byName) {
+
+		ch := byName[name]
+
+		if ch.State > report.State {
+			report.State = ch.State
+		}
+
+		report.Components = append(report.Components, ch)
+	}
+
+	cc.health.mutex.Lock()
+	stopping := cc.health.stopping
+	cc.health.mutex.Unlock()
+
+	report.Status = HealthStateLabel(report.State)
+	report.Live = report.State != HealthFailed
+	report.Ready = report.Live && accessAllowed && !blocking && !stopping
+
+	return report
+}
+
+func (cc *ComponentContainer) snapshotComponents(components []*Component) []*Component {
+
+	snapshot := make([]*Component, len(components))
+
+	for i, c := range components {
+		snapshot[i] = &Component{c.Instance, c.Name}
+	}
+
+	return snapshot
+}
+
+func reportHealth(reporter HealthReporter) (state int, detail string) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			state = HealthFailed
+			detail = fmt.Sprintf("panic: %v", r)
+		}
+	}()
+
+	state, detail = reporter.HealthStatus()
+
+	if state < HealthOK || state > HealthFailed {
+		return HealthFailed, fmt.Sprintf("unknown health state %d: %s", state, detail)
+	}
+
+	return state, detail
+}
+
+func recordHealth(byName map[string]*ComponentHealth, name string, state int, detail string) {
+
+	existing := byName[name]
+
+	if existing == nil {
+		byName[name] = &ComponentHealth{name, state, HealthStateLabel(state), detail}
+		return
+	}
+
+	if state > existing.State {
+		existing.State = state
+		existing.Status = HealthStateLabel(state)
+	}
+
+	if detail != "" {
+		if existing.Detail != "" {
+			existing.Detail += "; "
+		}
+
+		existing.Detail += detail
+	}
+}
+
+func sortedHealthNames(byName map[string]*ComponentHealth) []string {
+
+	names := make([]string, 0, len(byName))
+
+	for name := range byName {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (cc *ComponentContainer) startHealthMonitor() {
+
+	interval := cc.settings.HealthCheckInterval
+
+	if interval <= 0 {
+		return
+	}
+
+	hm := cc.health
+
+	hm.mutex.Lock()
+
+	if hm.stop != nil || hm.stopping {
+		hm.mutex.Unlock()
+		return
+	}
+
+	stop := make(chan bool)
+	hm.stop = stop
+	hm.mutex.Unlock()
+
+	cc.updateHealth()
+
+	go func() {
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				cc.updateHealth()
+			}
+		}
+	}()
+}
+
+func (cc *ComponentContainer) updateHealth() *HealthReport {
+
+	report := cc.CheckHealth()
+
+	hm := cc.health
+
+	hm.mutex.Lock()
+	previous := hm.latest
+	hm.latest = report
+	hm.mutex.Unlock()
+
+	if previous == nil || previous.State != report.State {
+
+		if report.State == HealthOK {
+			cc.FrameworkLogger.LogInfof("Container health is %s", report.Status)
+		} else {
+			cc.FrameworkLogger.LogWarnf("Container health is %s", report.Status)
+
+			for _, ch := range report.Components {
+				if ch.State != HealthOK {
+					cc.FrameworkLogger.LogWarnf("%s is %s: %s", ch.Name, ch.Status, ch.Detail)
+				}
+			}
+		}
+	}
+
+	return report
+}
+
+func (cc *ComponentContainer) beginShutdownHealth() *HealthReport {
+
+	hm := cc.health
+
+	hm.mutex.Lock()
+	hm.stopping = true
+
+	if hm.stop != nil {
+		close(hm.stop)
+		hm.stop = nil
+	}
+
+	hm.mutex.Unlock()
+
+	return cc.updateHealth()
+}
@@ -0,0 +1,228 @@
+package ioc
+
+import (
+	"fmt"
+)
+
+func (cc *ComponentContainer) ComponentByName(name string) (*Component, error) {
+
+	for {
+
+		cc.mutex.Lock()
+
+		component, err := cc.localComponentByName(name)
+		starting := cc.starting[name]
+		inherited := component == nil && err == nil
+
+		cc.mutex.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := cc.startLazyComponents(); err != nil {
+			return nil, err
+		}
+
+		if inherited {
+			return cc.parent.ComponentByName(name)
+		}
+
+		if starting == nil {
+			return component, nil
+		}
+
+		<-starting
+	}
+}
+
+func (cc *ComponentContainer) localComponentByName(name string) (*Component, error) {
+
+	if lazy := cc.deferred[name]; lazy != nil {
+		return cc.initialiseLazyComponent(lazy)
+	}
+
+	component := cc.allComponents[name]
+
+	if component == nil && cc.parent != nil && cc.protoComponents[name] == nil {
+		return nil, nil
+	}
+
+	if component == nil {
+		return nil, fmt.Errorf("No component named %s available", name)
+	}
+
+	return component, nil
+}
+
+func (cc *ComponentContainer) deferLazyComponents(ordered []*ProtoComponent) []*ProtoComponent {
+
+	required := make(map[string]bool)
+
+	for _, proto := range ordered {
+
+		_, isDecorator := proto.Component.Instance.(ComponentDecorator)
+
+		if proto.Scope != PrototypeScope && (!proto.Lazy || isDecorator) {
+			cc.markRequired(proto, required)
+		}
+	}
+
+	cc.deferred = make(map[string]*ProtoComponent)
+	cc.initialising = make(map[string]bool)
+	cc.starting = make(map[string]chan bool)
+	cc.lazyStarts = nil
+	eager := make([]*ProtoComponent, 0, len(ordered))
+
+	for _, proto := range ordered {
+
+		name := proto.Component.Name
+
+		if proto.Scope == PrototypeScope {
+			continue
+		}
+
+		if required[name] {
+			eager = append(eager, proto)
+		} else {
+			cc.FrameworkLogger.LogDebugf("Deferring initialisation of lazy component %s", name)
+			cc.deferred[name] = proto
+		}
+	}
+
+	return eager
+}
+
+func (cc *ComponentContainer) markRequired(proto *ProtoComponent, required map[string]bool) {
+
+	name := proto.Component.Name
+
+	if required[name] {
+		return
+	}
+
+	required[name] = true
+
+	for _, dep := range cc.dependencyEdges(proto) {
+
+		if depProto := cc.protoComponents[dep.componentName]; depProto != nil {
+			cc.markRequired(depProto, required)
+		}
+	}
+}
+
+func (cc *ComponentContainer) initialiseLazyComponent(proto *ProtoComponent) (*Component, error) {
+
+	component := proto.Component
+	name := component.Name
+
+	if cc.initialising[name] {
+		return component, nil
+	}
+
+	cc.FrameworkLogger.LogDebugf("Initialising lazy component %s", name)
+
+	cc.initialising[name] = true
+	defer delete(cc.initialising, name)
+
+	for _, dep := range cc.dependencyEdges(proto) {
+
+		if lazyDep := cc.deferred[dep.componentName]; lazyDep != nil {
+
+			if _, err := cc.initialiseLazyComponent(lazyDep); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := cc.resolveComponent(proto, component.Instance); err != nil {
+		return nil, err
+	}
+
+	cc.decorateComponent(component)
+
+	if err := validateComponent(component); err != nil {
+		return nil, err
+	}
+
+	delete(cc.deferred, name)
+	cc.addComponent(component)
+
+	if cc.started {
+		cc.starting[name] = make(chan bool)
+		cc.lazyStarts = append(cc.lazyStarts, component)
+	}
+
+	return component, nil
+}
+
+func (cc *ComponentContainer) startLazyComponents() error {
+
+	for {
+
+		cc.mutex.Lock()
+
+		if len(cc.lazyStarts) == 0 {
+			cc.mutex.Unlock()
+			return nil
+		}
+
+		component := cc.lazyStarts[0]
+		cc.lazyStarts = cc.lazyStarts[1:]
+
+		cc.mutex.Unlock()
+
+		err := cc.activateComponent(component)
+
+		cc.mutex.Lock()
+
+		failed := []*Component{}
+
+		if err != nil {
+			failed = append([]*Component{component}, cc.lazyStarts...)
+			cc.lazyStarts = nil
+		}
+
+		for _, c := range failed {
+			cc.FrameworkLogger.LogErrorf("Returning lazy component %s to uninitialised state as %s could not be started", c.Name, component.Name)
+			cc.removeComponent(c)
+		}
+
+		for _, c := range append(failed, component) {
+
+			if done := cc.starting[c.Name]; done != nil {
+				delete(cc.starting, c.Name)
+				close(done)
+			}
+		}
+
+		cc.mutex.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (cc *ComponentContainer) activateComponent(component *Component) error {
+
+	cc.mutex.Lock()
+	accessAllowed := cc.accessAllowed
+	cc.mutex.Unlock()
+
+	if _, startable := component.Instance.(Startable); startable {
+
+		if err := cc.startComponent(component); err != nil {
+			return err
+		}
+	}
+
+	if accessible, found := component.Instance.(Accessible); found && accessAllowed {
+
+		if err := accessible.AllowAccess(); err != nil {
+			return &ComponentStartError{component.Name, err}
+		}
+	}
+
+	return nil
+}
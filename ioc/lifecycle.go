@@ -17,3 +17,19 @@ type AccessibilityBlocker interface {
 type Accessible interface {
 	AllowAccess() error
 }
+
+type Validatable interface {
+	ValidateComponent() error
+}
+
+type Cleanable interface {
+	CleanupComponent() error
+}
+
+type HealthReporter interface {
+	HealthStatus() (int, string)
+}
+
+type ReloadListener interface {
+	ComponentsReloaded(names []string) error
+}
@@ -0,0 +1,125 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+func (pc *ProtoComponent) propertyFields() []string {
+
+	fields := make([]string, 0, len(pc.Properties))
+
+	for field := range pc.Properties {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+func (cc *ComponentContainer) validateProperties(proto *ProtoComponent, problems []error) []error {
+
+	componentName := proto.Component.Name
+
+	for _, fieldName := range proto.propertyFields() {
+
+		field, problem := settableField(proto, fieldName)
+
+		if problem != nil {
+			problems = append(problems, problem)
+			continue
+		}
+
+		if _, err := propertyValue(proto.Properties[fieldName], field.Type()); err != nil {
+			problems = append(problems, fmt.Errorf("Unable to set %s.%s: %s", componentName, fieldName, err))
+		}
+	}
+
+	return problems
+}
+
+func applyProperties(proto *ProtoComponent, instance interface{}) error {
+
+	targetReflect := reflect.ValueOf(instance).Elem()
+
+	for _, fieldName := range proto.propertyFields() {
+
+		targetField := targetReflect.FieldByName(fieldName)
+		value, err := propertyValue(proto.Properties[fieldName], targetField.Type())
+
+		if err != nil {
+			return fmt.Errorf("Unable to set %s.%s: %s", proto.Component.Name, fieldName, err)
+		}
+
+		targetField.Set(value)
+	}
+
+	return nil
+}
+
+func propertyValue(value interface{}, fieldType reflect.Type) (reflect.Value, error) {
+
+	v := reflect.ValueOf(value)
+
+	if !v.IsValid() {
+		return reflect.Zero(fieldType), nil
+	}
+
+	if v.Type().AssignableTo(fieldType) {
+		return copyValue(v), nil
+	}
+
+	if v.Kind() == fieldType.Kind() && v.Type().ConvertibleTo(fieldType) {
+		return copyValue(v).Convert(fieldType), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("a value of type %s cannot be assigned to a field of type %s", v.Type(), fieldType)
+}
+
+func copyValue(v reflect.Value) reflect.Value {
+
+	switch v.Kind() {
+	case reflect.Slice:
+
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i)))
+		}
+
+		return c
+
+	case reflect.Map:
+
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+
+		for _, key := range v.MapKeys() {
+			c.SetMapIndex(key, copyValue(v.MapIndex(key)))
+		}
+
+		return c
+
+	case reflect.Interface:
+
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.New(v.Type()).Elem()
+		c.Set(copyValue(v.Elem()))
+
+		return c
+	}
+
+	return v
+}
@@ -0,0 +1,484 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"github.com/wolferton/quilt/config"
+	"reflect"
+	"sort"
+	"time"
+)
+
+type ReloadReport struct {
+	Changed []string
+	Rebuilt []string
+	Elapsed time.Duration
+}
+
+type reloadState struct {
+	rebuild      []*ProtoComponent
+	previous     map[string]interface{}
+	previousCa   *config.ConfigAccessor
+	redecorators []*namedDecorator
+}
+
+func (cc *ComponentContainer) Reload(ca *config.ConfigAccessor) (*ReloadReport, error) {
+
+	cc.reloadMutex.Lock()
+	defer cc.reloadMutex.Unlock()
+
+	start := time.Now()
+	report := new(ReloadReport)
+
+	cc.mutex.Lock()
+	state, err := cc.prepareReload(ca, report)
+	cc.mutex.Unlock()
+
+	if err != nil || state == nil {
+		return report, err
+	}
+
+	cc.FrameworkLogger.LogInfof("Reloading %v", report.Rebuilt)
+
+	err = cc.restartReloaded(state)
+
+	if err == nil {
+		err = cc.startLazyComponents()
+	}
+
+	if err != nil {
+		return report, err
+	}
+
+	report.Elapsed = time.Since(start)
+
+	return report, cc.notifyReloadListeners(report.Rebuilt)
+}
+
+func (cc *ComponentContainer) prepareReload(ca *config.ConfigAccessor, report *ReloadReport) (*reloadState, error) {
+
+	if !cc.populated {
+		return nil, fmt.Errorf("Container must be populated before components can be reloaded")
+	}
+
+	if changed := cc.changedConditions(ca); len(changed) > 0 {
+		return nil, fmt.Errorf("Configuration changes alter the conditions for components %v. Components cannot be added or removed without a restart", changed)
+	}
+
+	report.Changed = cc.changedComponents(cc.configAccessor, ca)
+
+	state := new(reloadState)
+	state.previous = make(map[string]interface{})
+	state.previousCa = cc.configAccessor
+
+	cc.configAccessor = ca
+
+	if len(report.Changed) == 0 {
+		cc.FrameworkLogger.LogInfof("No components affected by configuration changes")
+		return nil, nil
+	}
+
+	affected := cc.affectedComponents(report.Changed)
+
+	ordered, err := cc.dependencyOrder()
+
+	if err != nil {
+		cc.configAccessor = state.previousCa
+		return nil, err
+	}
+
+	for _, proto := range ordered {
+
+		name := proto.Component.Name
+
+		if !affected[name] {
+			continue
+		}
+
+		if lazy := cc.deferred[name]; lazy != nil {
+			err = cc.refreshDeferred(lazy)
+		} else if cc.allComponents[name] != nil {
+			err = cc.rebuildComponent(proto, state)
+			report.Rebuilt = append(report.Rebuilt, name)
+		}
+
+		if err != nil {
+			cc.restoreReloaded(state)
+			return nil, err
+		}
+	}
+
+	if len(state.rebuild) == 0 {
+		return nil, nil
+	}
+
+	for _, proto := range state.rebuild {
+		cc.starting[proto.Component.Name] = make(chan bool)
+	}
+
+	return state, nil
+}
+
+func (cc *ComponentContainer) changedConditions(ca *config.ConfigAccessor) []string {
+
+	changed := []string{}
+
+	for name, proto := range cc.protoComponents {
+
+		if unsatisfiedCondition(proto, ca, cc.activeProfiles) != nil {
+			changed = append(changed, name)
+		}
+	}
+
+	for name, ec := range cc.excluded {
+
+		if unsatisfiedCondition(ec.proto, ca, cc.activeProfiles) == nil {
+			changed = append(changed, name)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+func (cc *ComponentContainer) changedComponents(current, reloaded *config.ConfigAccessor) []string {
+
+	changed := []string{}
+
+	for _, name := range cc.sortedProtoNames() {
+
+		proto := cc.protoComponents[name]
+
+		if proto.Scope == PrototypeScope {
+			continue
+		}
+
+		for _, path := range proto.configPaths() {
+
+			if !reflect.DeepEqual(current.Value(path), reloaded.Value(path)) {
+				changed = append(changed, name)
+				break
+			}
+		}
+	}
+
+	return changed
+}
+
+func (pc *ProtoComponent) configPaths() []string {
+
+	paths := append([]string{}, pc.ConfigRoots...)
+
+	for _, fieldName := range sortedKeys(pc.ConfigPromises) {
+		paths = append(paths, pc.ConfigPromises[fieldName])
+	}
+
+	return paths
+}
+
+func (cc *ComponentContainer) affectedComponents(changed []string) map[string]bool {
+
+	dependents := make(map[string][]string)
+
+	for name, proto := range cc.protoComponents {
+
+		if proto.Scope == PrototypeScope {
+			continue
+		}
+
+		for _, dep := range cc.dependencyEdges(proto) {
+			dependents[dep.componentName] = append(dependents[dep.componentName], name)
+		}
+	}
+
+	affected := make(map[string]bool)
+	pending := append([]string{}, changed...)
+
+	for len(pending) > 0 {
+
+		name := pending[0]
+		pending = pending[1:]
+
+		if affected[name] {
+			continue
+		}
+
+		affected[name] = true
+		pending = append(pending, dependents[name]...)
+	}
+
+	return affected
+}
+
+func (cc *ComponentContainer) refreshDeferred(proto *ProtoComponent) error {
+
+	instance, err := cc.freshInstance(proto)
+
+	if err != nil {
+		return err
+	}
+
+	proto.Component.Instance = instance
+
+	return nil
+}
+
+func (cc *ComponentContainer) rebuildComponent(proto *ProtoComponent, state *reloadState) error {
+
+	component := proto.Component
+	instance, err := cc.freshInstance(proto)
+
+	if err != nil {
+		return err
+	}
+
+	state.rebuild = append(state.rebuild, proto)
+	state.previous[component.Name] = component.Instance
+
+	component.Instance = instance
+
+	if nd := cc.replaceDecorator(component); nd != nil {
+		state.redecorators = append(state.redecorators, nd)
+	}
+
+	if err := cc.resolveComponent(proto, component.Instance); err != nil {
+		return err
+	}
+
+	cc.decorateComponent(component)
+
+	return validateComponent(component)
+}
+
+func (cc *ComponentContainer) freshInstance(proto *ProtoComponent) (interface{}, error) {
+
+	name := proto.Component.Name
+
+	if proto.Builder != nil {
+		return proto.Builder(cc.configAccessor)
+	}
+
+	if !isStructPointer(proto.Component.Instance) {
+		return nil, fmt.Errorf("Component %s is affected by configuration changes but is not a pointer to a struct and has no Builder, so cannot be reloaded", name)
+	}
+
+	instance := reflect.New(reflect.TypeOf(proto.Component.Instance).Elem()).Interface()
+
+	for _, root := range proto.ConfigRoots {
+
+		if err := cc.configAccessor.Populate(root, instance); err != nil {
+			return nil, err
+		}
+	}
+
+	return instance, nil
+}
+
+func (cc *ComponentContainer) replaceDecorator(component *Component) *namedDecorator {
+
+	decorator, isDecorator := component.Instance.(ComponentDecorator)
+
+	if !isDecorator {
+		return nil
+	}
+
+	for _, nd := range cc.decorators {
+
+		if nd.name == component.Name {
+			nd.decorator = decorator
+			return nd
+		}
+	}
+
+	return nil
+}
+
+func (cc *ComponentContainer) restoreReloaded(state *reloadState) {
+
+	for _, proto := range state.rebuild {
+
+		component := proto.Component
+		component.Instance = state.previous[component.Name]
+
+		cc.replaceDecorator(component)
+	}
+
+	cc.configAccessor = state.previousCa
+}
+
+func (cc *ComponentContainer) restartReloaded(state *reloadState) error {
+
+	cc.mutex.Lock()
+	started := cc.started
+	cc.mutex.Unlock()
+
+	if !started {
+		cc.finishReload(state)
+		return nil
+	}
+
+	cc.stopInstances(state.rebuild, state.previous)
+
+	for i, proto := range state.rebuild {
+
+		if err := cc.activateComponent(proto.Component); err != nil {
+			cc.FrameworkLogger.LogErrorf("Unable to start %s after reload, restoring previous components and configuration: %s", proto.Component.Name, err)
+			cc.rollbackReload(state, state.rebuild[:i])
+
+			return err
+		}
+
+		cc.releaseStarting(proto.Component.Name)
+	}
+
+	cc.redecorate(state)
+	cc.finishReload(state)
+
+	return nil
+}
+
+func (cc *ComponentContainer) rollbackReload(state *reloadState, started []*ProtoComponent) {
+
+	current := make(map[string]interface{})
+
+	for _, proto := range started {
+		current[proto.Component.Name] = proto.Component.Instance
+	}
+
+	cc.stopInstances(started, current)
+
+	cc.mutex.Lock()
+	cc.restoreReloaded(state)
+	cc.mutex.Unlock()
+
+	for _, proto := range state.rebuild {
+
+		if err := cc.activateComponent(proto.Component); err != nil {
+			cc.FrameworkLogger.LogErrorf("Unable to restart %s after a failed reload: %s", proto.Component.Name, err)
+		}
+	}
+
+	cc.finishReload(state)
+}
+
+func (cc *ComponentContainer) redecorate(state *reloadState) {
+
+	if len(state.redecorators) == 0 {
+		return
+	}
+
+	rebuilt := make(map[string]bool)
+
+	for _, proto := range state.rebuild {
+		rebuilt[proto.Component.Name] = true
+	}
+
+	cc.mutex.Lock()
+	components := make([]*Component, 0, len(cc.allComponents))
+
+	for _, name := range sortedComponentNames(cc.allComponents) {
+
+		if !rebuilt[name] {
+			components = append(components, cc.allComponents[name])
+		}
+	}
+
+	cc.mutex.Unlock()
+
+	for _, nd := range state.redecorators {
+
+		for _, component := range components {
+
+			if nd.decorator.OfInterest(component) {
+				nd.decorator.DecorateComponent(component, cc)
+			}
+		}
+	}
+}
+
+func (cc *ComponentContainer) finishReload(state *reloadState) {
+
+	for _, proto := range state.rebuild {
+		cc.releaseStarting(proto.Component.Name)
+	}
+}
+
+func (cc *ComponentContainer) releaseStarting(name string) {
+
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	if done := cc.starting[name]; done != nil {
+		delete(cc.starting, name)
+		close(done)
+	}
+}
+
+func (cc *ComponentContainer) stopInstances(protos []*ProtoComponent, instances map[string]interface{}) {
+
+	for i := len(protos) - 1; i >= 0; i-- {
+
+		name := protos[i].Component.Name
+		component := &Component{Name: name, Instance: instances[name]}
+
+		if stoppable, found := component.Instance.(Stoppable); found {
+
+			stoppable.PrepareToStop()
+
+			if err := cc.stopWithTimeout(context.Background(), component, cc.settings.stopTimeoutFor(name)); err != nil {
+				cc.FrameworkLogger.LogWarnf("%s did not stop cleanly before reload: %s", name, err)
+			}
+		}
+
+		if _, found := component.Instance.(Cleanable); found {
+
+			if err := cc.cleanupComponent(component); err != nil {
+				cc.FrameworkLogger.LogWarnf("%s was not cleaned up before reload: %s", name, err)
+			}
+		}
+	}
+}
+
+func (cc *ComponentContainer) notifyReloadListeners(rebuiltNames []string) error {
+
+	rebuilt := make(map[string]bool)
+
+	for _, name := range rebuiltNames {
+		rebuilt[name] = true
+	}
+
+	cc.mutex.Lock()
+	listeners := make(map[string]ReloadListener)
+
+	for name, component := range cc.allComponents {
+
+		if listener, found := component.Instance.(ReloadListener); found && !rebuilt[name] {
+			listeners[name] = listener
+		}
+	}
+
+	cc.mutex.Unlock()
+
+	for name, listener := range listeners {
+
+		if err := listener.ComponentsReloaded(rebuiltNames); err != nil {
+			return &ComponentStartError{name, err}
+		}
+	}
+
+	return nil
+}
+
+func sortedComponentNames(components map[string]*Component) []string {
+
+	names := make([]string, 0, len(components))
+
+	for name := range components {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,73 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var componentFactoryType = reflect.TypeOf(new(ComponentFactory))
+
+type ComponentFactory struct {
+	container *ComponentContainer
+	name      string
+}
+
+func (cf *ComponentFactory) Create() (interface{}, error) {
+	return cf.container.NewInstance(cf.name)
+}
+
+func (cc *ComponentContainer) NewInstance(name string) (interface{}, error) {
+
+	cc.mutex.Lock()
+
+	proto := cc.prototypes[name]
+
+	if proto == nil {
+		cc.mutex.Unlock()
+		return nil, fmt.Errorf("No component named %s with prototype scope available", name)
+	}
+
+	component, err := cc.createPrototypeInstance(proto)
+
+	cc.mutex.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cc.startLazyComponents(); err != nil {
+		return nil, err
+	}
+
+	return component.Instance, nil
+}
+
+func (cc *ComponentContainer) createPrototypeInstance(proto *ProtoComponent) (*Component, error) {
+
+	instance := reflect.New(reflect.TypeOf(proto.Component.Instance).Elem())
+
+	component := new(Component)
+	component.Name = proto.Component.Name
+	component.Instance = instance.Interface()
+
+	cc.FrameworkLogger.LogTracef("Creating new instance of %s", component.Name)
+
+	err := cc.resolveComponent(proto, component.Instance)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cc.populated {
+		cc.decorateComponent(component)
+
+		if err := validateComponent(component); err != nil {
+			return nil, err
+		}
+
+	} else {
+		cc.pendingPrototypes = append(cc.pendingPrototypes, component)
+	}
+
+	return component, nil
+}
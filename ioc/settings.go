@@ -0,0 +1,93 @@
+package ioc
+
+import (
+	"github.com/wolferton/quilt/config"
+	"time"
+)
+
+const containerSettingsPath = "ComponentContainer"
+
+type ContainerSettings struct {
+	StartTimeout           time.Duration
+	ComponentStartTimeout  time.Duration
+	ComponentStartTimeouts map[string]time.Duration
+	ParallelStart          bool
+	BlockerRetestInterval  time.Duration
+	BlockerMaxTries        int
+	ShutdownTimeout        time.Duration
+	ComponentStopTimeout   time.Duration
+	ComponentStopTimeouts  map[string]time.Duration
+	ReadyToStopInterval    time.Duration
+	HotReload              bool
+	HealthCheckInterval    time.Duration
+}
+
+func defaultContainerSettings() *ContainerSettings {
+	cs := new(ContainerSettings)
+	cs.BlockerRetestInterval = 5 * time.Second
+	cs.BlockerMaxTries = 12
+	cs.ShutdownTimeout = 60 * time.Second
+	cs.ReadyToStopInterval = 5 * time.Second
+	cs.HealthCheckInterval = 10 * time.Second
+
+	return cs
+}
+
+func ContainerSettingsSchema() *config.ConfigSchema {
+
+	s := config.NewConfigSchema(containerSettingsPath)
+	s.Field("StartTimeout", config.SchemaDuration)
+	s.Field("ComponentStartTimeout", config.SchemaDuration)
+	s.Field("ComponentStartTimeouts", config.SchemaObject)
+	s.Field("ParallelStart", config.SchemaBool)
+	s.Field("BlockerRetestInterval", config.SchemaDuration)
+	s.Field("BlockerMaxTries", config.SchemaInt)
+	s.Field("ShutdownTimeout", config.SchemaDuration)
+	s.Field("ComponentStopTimeout", config.SchemaDuration)
+	s.Field("ComponentStopTimeouts", config.SchemaObject)
+	s.Field("ReadyToStopInterval", config.SchemaDuration)
+	s.Field("HotReload", config.SchemaBool)
+	s.Field("HealthCheckInterval", config.SchemaDuration)
+
+	return s
+}
+
+func (cc *ComponentContainer) loadSettings() error {
+
+	cs := defaultContainerSettings()
+
+	if cc.configAccessor.PathExists(containerSettingsPath) {
+
+		err := cc.configAccessor.Populate(containerSettingsPath, cs)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	cc.settings = cs
+
+	return nil
+}
+
+func (cs *ContainerSettings) startTimeoutFor(componentName string) time.Duration {
+
+	if timeout, found := cs.ComponentStartTimeouts[componentName]; found {
+		return timeout
+	}
+
+	return cs.ComponentStartTimeout
+}
+
+func (cs *ContainerSettings) stopTimeoutFor(componentName string) time.Duration {
+
+	if timeout, found := cs.ComponentStopTimeouts[componentName]; found {
+		return timeout
+	}
+
+	return cs.ComponentStopTimeout
+}
+
+func (cc *ComponentContainer) Settings() *ContainerSettings {
+	return cc.settings
+}
@@ -0,0 +1,217 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const readyToStopMaxTries = 10
+
+type ShutdownReport struct {
+	NotReady   []string
+	Components []*ComponentStopTime
+	Cleanup    []*ComponentStopTime
+	Health     *HealthReport
+	Elapsed    time.Duration
+}
+
+type ComponentStopTime struct {
+	Name    string
+	Elapsed time.Duration
+	Err     error
+}
+
+func (sr *ShutdownReport) Clean() bool {
+
+	if len(sr.NotReady) > 0 {
+		return false
+	}
+
+	return len(sr.Errored()) == 0
+}
+
+func (sr *ShutdownReport) Errored() []*ComponentStopTime {
+
+	errored := []*ComponentStopTime{}
+
+	for _, cst := range sr.Components {
+		if cst.Err != nil {
+			errored = append(errored, cst)
+		}
+	}
+
+	for _, cst := range sr.Cleanup {
+		if cst.Err != nil {
+			errored = append(errored, cst)
+		}
+	}
+
+	return errored
+}
+
+func (cc *ComponentContainer) ShutdownReport() *ShutdownReport {
+	return cc.shutdownReport
+}
+
+func (cc *ComponentContainer) ShutdownComponents(ctx context.Context) *ShutdownReport {
+
+	report := new(ShutdownReport)
+	cc.shutdownReport = report
+
+	began := time.Now()
+
+	settings := cc.settings
+
+	report.Health = cc.beginShutdownHealth()
+
+	if settings.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, settings.ShutdownTimeout)
+		defer cancel()
+	}
+
+	stoppable := cc.lifecycleComponents(&cc.stoppable)
+
+	for i := len(stoppable) - 1; i >= 0; i-- {
+
+		s := stoppable[i].Instance.(Stoppable)
+		s.PrepareToStop()
+	}
+
+	report.NotReady = cc.waitForReadyToStop(ctx, settings.ReadyToStopInterval)
+
+	if len(report.NotReady) > 0 {
+		cc.FrameworkLogger.LogWarnf("Some components not ready to stop (%v), stopping anyway", report.NotReady)
+	}
+
+	for i := len(stoppable) - 1; i >= 0; i-- {
+
+		c := stoppable[i]
+		stopBegan := time.Now()
+
+		err := cc.stopWithTimeout(ctx, c, settings.stopTimeoutFor(c.Name))
+
+		if err != nil {
+			cc.FrameworkLogger.LogErrorf("%s did not stop cleanly %s", c.Name, err)
+		}
+
+		report.Components = append(report.Components, &ComponentStopTime{c.Name, time.Since(stopBegan), err})
+	}
+
+	cleanable := cc.lifecycleComponents(&cc.cleanable)
+
+	for i := len(cleanable) - 1; i >= 0; i-- {
+
+		c := cleanable[i]
+		cleanupBegan := time.Now()
+
+		err := cc.cleanupComponent(c)
+
+		if err != nil {
+			cc.FrameworkLogger.LogErrorf("%s did not clean up cleanly %s", c.Name, err)
+		}
+
+		report.Cleanup = append(report.Cleanup, &ComponentStopTime{c.Name, time.Since(cleanupBegan), err})
+	}
+
+	report.Elapsed = time.Since(began)
+
+	return report
+}
+
+func (cc *ComponentContainer) cleanupComponent(c *Component) (err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			cc.FrameworkLogger.LogErrorfWithTrace("Panic recovered while cleaning up component %s %s", c.Name, r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return c.Instance.(Cleanable).CleanupComponent()
+}
+
+func (cc *ComponentContainer) waitForReadyToStop(ctx context.Context, retestInterval time.Duration) []string {
+
+	_, hasDeadline := ctx.Deadline()
+
+	for attempt := 1; ; attempt++ {
+
+		notReady := cc.notReadyToStop(attempt > 1)
+
+		if len(notReady) == 0 || (!hasDeadline && attempt >= readyToStopMaxTries) {
+			return notReady
+		}
+
+		select {
+		case <-ctx.Done():
+			return notReady
+		case <-time.After(retestInterval):
+		}
+	}
+}
+
+func (cc *ComponentContainer) stopWithTimeout(ctx context.Context, c *Component, timeout time.Duration) error {
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("shutdown deadline passed, not stopping %s", c.Name)
+	}
+
+	stopped := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				stopped <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+
+		stopped <- c.Instance.(Stoppable).Stop()
+	}()
+
+	var timedOut <-chan time.Time
+
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		timedOut = timer.C
+	}
+
+	select {
+	case err := <-stopped:
+		return err
+	case <-timedOut:
+		return fmt.Errorf("did not stop within %s", timeout)
+	case <-ctx.Done():
+		return fmt.Errorf("did not stop before the shutdown deadline: %s", ctx.Err())
+	}
+}
+
+func (cc *ComponentContainer) notReadyToStop(warn bool) []string {
+
+	notReady := []string{}
+
+	for _, c := range cc.lifecycleComponents(&cc.stoppable) {
+		s := c.Instance.(Stoppable)
+
+		ready, err := s.ReadyToStop()
+
+		if !ready {
+			notReady = append(notReady, c.Name)
+
+			if warn {
+				if err != nil {
+					cc.FrameworkLogger.LogWarnf("%s is not ready to stop: %s", c.Name, err)
+				} else {
+					cc.FrameworkLogger.LogWarnf("%s is not ready to stop (no reason given)", c.Name)
+				}
+
+			}
+		}
+
+	}
+
+	return notReady
+}
@@ -0,0 +1,237 @@
+package ioc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type StartupReport struct {
+	Components []*ComponentStartTime
+	Elapsed    time.Duration
+	mutex      sync.Mutex
+}
+
+type ComponentStartTime struct {
+	Name      string
+	Elapsed   time.Duration
+	Err       error
+	Abandoned bool
+}
+
+func (sr *StartupReport) record(name string, elapsed time.Duration, err error, abandoned bool) {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+
+	sr.Components = append(sr.Components, &ComponentStartTime{name, elapsed, err, abandoned})
+}
+
+func (cc *ComponentContainer) StartupReport() *StartupReport {
+	return cc.startupReport
+}
+
+func (cc *ComponentContainer) startAll(deadline time.Time) error {
+
+	report := new(StartupReport)
+	cc.startupReport = report
+
+	began := time.Now()
+
+	var err error
+
+	if cc.settings.ParallelStart {
+		err = cc.startInParallel(deadline)
+	} else {
+		err = cc.startSerially(deadline)
+	}
+
+	report.Elapsed = time.Since(began)
+
+	cc.logStartupReport(report)
+
+	return err
+}
+
+func (cc *ComponentContainer) startSerially(deadline time.Time) error {
+
+	for _, component := range cc.lifecycleComponents(&cc.startable) {
+
+		err := cc.startWithTimeout(component, deadline)
+
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+}
+
+type startResult struct {
+	done chan bool
+	err  error
+}
+
+func (cc *ComponentContainer) startInParallel(deadline time.Time) error {
+
+	startable := cc.lifecycleComponents(&cc.startable)
+	results := make(map[string]*startResult)
+
+	for _, component := range startable {
+		results[component.Name] = &startResult{done: make(chan bool)}
+	}
+
+	for _, component := range startable {
+		go cc.startWhenDependenciesStarted(component, results, deadline)
+	}
+
+	for _, component := range startable {
+		<-results[component.Name].done
+	}
+
+	for _, component := range startable {
+
+		if err := results[component.Name].err; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cc *ComponentContainer) startWhenDependenciesStarted(component *Component, results map[string]*startResult, deadline time.Time) {
+
+	result := results[component.Name]
+	defer close(result.done)
+
+	for _, depName := range cc.startableDependencies(component.Name) {
+
+		depResult := results[depName]
+
+		if depResult == nil {
+			continue
+		}
+
+		<-depResult.done
+
+		if depResult.err != nil {
+			result.err = &ComponentStartError{component.Name, fmt.Errorf("dependency %s did not start", depName)}
+			return
+		}
+	}
+
+	result.err = cc.startWithTimeout(component, deadline)
+}
+
+func (cc *ComponentContainer) startableDependencies(name string) []string {
+
+	visited := make(map[string]bool)
+
+	return cc.collectStartableDependencies(name, visited, []string{})
+}
+
+func (cc *ComponentContainer) collectStartableDependencies(name string, visited map[string]bool, found []string) []string {
+
+	proto := cc.protoComponents[name]
+
+	if proto == nil {
+		return found
+	}
+
+	for _, dep := range cc.dependencyEdges(proto) {
+
+		depName := dep.componentName
+
+		if visited[depName] || proto.ToleratedCycles[dep.fieldName] {
+			continue
+		}
+
+		visited[depName] = true
+
+		if depProto := cc.protoComponents[depName]; depProto != nil {
+
+			if _, startable := depProto.Component.Instance.(Startable); startable {
+				found = append(found, depName)
+			}
+		}
+
+		found = cc.collectStartableDependencies(depName, visited, found)
+	}
+
+	return found
+}
+
+func (cc *ComponentContainer) startWithTimeout(component *Component, deadline time.Time) error {
+
+	timeout := cc.settings.startTimeoutFor(component.Name)
+
+	if !deadline.IsZero() {
+
+		remaining := deadline.Sub(time.Now())
+
+		if timeout <= 0 || remaining < timeout {
+			timeout = remaining
+		}
+	}
+
+	began := time.Now()
+	started := make(chan error, 1)
+
+	go func() {
+		started <- cc.startComponent(component)
+	}()
+
+	if timeout <= 0 && deadline.IsZero() {
+		err := <-started
+		cc.startupReport.record(component.Name, time.Since(began), err, false)
+
+		return err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-started:
+		cc.startupReport.record(component.Name, time.Since(began), err, false)
+
+		return err
+
+	case <-timer.C:
+		err := &ComponentStartError{component.Name, fmt.Errorf("did not start within %s", timeout)}
+
+		cc.FrameworkLogger.LogErrorf("Abandoning start of %s after %s, its StartComponent method is still running", component.Name, timeout)
+		cc.startupReport.record(component.Name, time.Since(began), err, true)
+
+		go cc.logAbandonedStart(component, began, started)
+
+		return err
+	}
+}
+
+func (cc *ComponentContainer) logAbandonedStart(component *Component, began time.Time, started <-chan error) {
+
+	if err := <-started; err != nil {
+		cc.FrameworkLogger.LogWarnf("Abandoned component %s failed to start after %s: %s", component.Name, time.Since(began), err)
+	} else {
+		cc.FrameworkLogger.LogWarnf("Abandoned component %s finished starting after %s", component.Name, time.Since(began))
+	}
+}
+
+func (cc *ComponentContainer) logStartupReport(report *StartupReport) {
+
+	l := cc.FrameworkLogger
+
+	for _, cst := range report.Components {
+
+		if cst.Abandoned {
+			l.LogInfof("%s abandoned after %s", cst.Name, cst.Elapsed)
+		} else if cst.Err != nil {
+			l.LogInfof("%s failed to start after %s", cst.Name, cst.Elapsed)
+		} else {
+			l.LogInfof("%s started in %s", cst.Name, cst.Elapsed)
+		}
+	}
+
+	l.LogInfof("%d component(s) started in %s", len(report.Components), report.Elapsed)
+}
@@ -0,0 +1,153 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+func (cc *ComponentContainer) validateProtos(problems []error) []error {
+
+	for _, name := range cc.sortedProtoNames() {
+
+		proto := cc.protoComponents[name]
+
+		if proto.Scope == PrototypeScope && !isStructPointer(proto.Component.Instance) {
+			problems = append(problems, fmt.Errorf("Component %s cannot have prototype scope as it is not a pointer to a struct", name))
+			continue
+		}
+
+		problems = cc.validateDependencies(proto, problems)
+		problems = cc.validateCollectionDependencies(proto, problems)
+		problems = cc.validateConfigPromises(proto, problems)
+		problems = cc.validateProperties(proto, problems)
+	}
+
+	return problems
+}
+
+func (cc *ComponentContainer) validateDependencies(proto *ProtoComponent, problems []error) []error {
+
+	componentName := proto.Component.Name
+	w := cc.wiringFor(proto)
+
+	for _, fieldName := range sortedKeys(w.dependencies) {
+
+		depName := w.dependencies[fieldName]
+		field, problem := settableField(proto, fieldName)
+
+		if problem != nil {
+			problems = append(problems, problem)
+			continue
+		}
+
+		dep := cc.findProto(depName)
+
+		if dep == nil {
+			problems = append(problems, &MissingDependencyError{componentName, fieldName, depName})
+			continue
+		}
+
+		depType := reflect.TypeOf(dep.Component.Instance)
+
+		if dep.Scope == PrototypeScope && field.Type() == componentFactoryType {
+			continue
+		}
+
+		if !depType.AssignableTo(field.Type()) {
+			problems = append(problems, fmt.Errorf("Component %s is of type %s which cannot be assigned to %s.%s (type %s)", depName, depType, componentName, fieldName, field.Type()))
+		}
+	}
+
+	return problems
+}
+
+func (cc *ComponentContainer) validateCollectionDependencies(proto *ProtoComponent, problems []error) []error {
+
+	componentName := proto.Component.Name
+	w := cc.wiringFor(proto)
+
+	for _, fieldName := range w.collectionFields() {
+
+		members := w.collections[fieldName]
+		field, problem := settableField(proto, fieldName)
+
+		if problem != nil {
+			problems = append(problems, problem)
+			continue
+		}
+
+		if !isCollectionType(field.Type()) {
+			problems = append(problems, fmt.Errorf("%s.%s is of type %s and cannot be populated with a collection of components", componentName, fieldName, field.Type()))
+			continue
+		}
+
+		elemType := field.Type().Elem()
+
+		for _, memberName := range members {
+
+			member := cc.findProto(memberName)
+
+			if member == nil {
+				problems = append(problems, &MissingDependencyError{componentName, fieldName, memberName})
+				continue
+			}
+
+			memberType := reflect.TypeOf(member.Component.Instance)
+
+			if !memberType.AssignableTo(elemType) {
+				problems = append(problems, fmt.Errorf("Component %s is of type %s which cannot be added to %s.%s (element type %s)", memberName, memberType, componentName, fieldName, elemType))
+			}
+		}
+	}
+
+	return problems
+}
+
+func (cc *ComponentContainer) validateConfigPromises(proto *ProtoComponent, problems []error) []error {
+
+	componentName := proto.Component.Name
+
+	for _, fieldName := range sortedKeys(proto.ConfigPromises) {
+
+		configPath := proto.ConfigPromises[fieldName]
+
+		if _, problem := settableField(proto, fieldName); problem != nil {
+			problems = append(problems, problem)
+			continue
+		}
+
+		if !cc.configAccessor.PathExists(configPath) {
+			problems = append(problems, fmt.Errorf("No configuration found at path %s (required by %s.%s)", configPath, componentName, fieldName))
+		}
+	}
+
+	return problems
+}
+
+func isStructPointer(instance interface{}) bool {
+	t := reflect.TypeOf(instance)
+
+	return t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
+}
+
+func settableField(proto *ProtoComponent, fieldName string) (reflect.Value, error) {
+
+	componentName := proto.Component.Name
+	instance := reflect.ValueOf(proto.Component.Instance)
+
+	if !isStructPointer(proto.Component.Instance) {
+		return reflect.Value{}, fmt.Errorf("Unable to set %s.%s as component %s is not a pointer to a struct", componentName, fieldName, componentName)
+	}
+
+	field := instance.Elem().FieldByName(fieldName)
+
+	if !field.IsValid() {
+		return field, fmt.Errorf("Component %s (type %s) has no field named %s", componentName, instance.Type(), fieldName)
+	}
+
+	if !field.CanSet() {
+		return field, fmt.Errorf("Unable to set %s.%s as the field is not exported", componentName, fieldName)
+	}
+
+	return field, nil
+}